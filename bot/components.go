@@ -2,81 +2,216 @@ package bot
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"super-bot/core"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// CreateNodeButtons creates interactive buttons for VPN node selection
+// maxGroupMenus is how many group select menus fit: Discord allows max 5 Action Rows per
+// message and the last two are reserved for the Clash mode buttons and dashboard controls
+const maxGroupMenus = 3
+
+// CreateNodeButtons creates one node picker (select menu) per exposed Sing-box group
+// plus the dashboard control row
 func CreateNodeButtons(data *core.DashboardData) []discordgo.MessageComponent {
 	var components []discordgo.MessageComponent
-	var currentRow []discordgo.MessageComponent
 
-	// Add node buttons (max 24 buttons to allow refresh button as the 25th)
-	// Discord allows max 5 Action Rows per message, max 5 buttons per row
-	for i, node := range data.Singbox.AllNodes {
-		if i >= 24 {
-			break
-		}
+	for _, group := range menuGroups(data.Singbox.Groups) {
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{groupSelectMenu(group, data)},
+		})
+	}
 
-		// Shorten display name
-		display := strings.ReplaceAll(node, "WG-Solid-", "")
-		display = strings.ReplaceAll(display, "WG-", "")
+	if data.Singbox.Mode != "" {
+		components = append(components, discordgo.ActionsRow{
+			Components: createModeButtons(data),
+		})
+	}
+
+	components = append(components, discordgo.ActionsRow{
+		Components: createControlButtons(data),
+	})
+
+	return components
+}
+
+// menuGroups returns the groups that get a select menu, and hiddenGroups how many are left out
+func menuGroups(groups []core.ProxyGroup) []core.ProxyGroup {
+	var shown []core.ProxyGroup
+	for _, group := range groups {
+		// Discord rejects select menus without options
+		if len(group.All) > 0 && len(shown) < maxGroupMenus {
+			shown = append(shown, group)
+		}
+	}
+	return shown
+}
 
-		// Get delay
-		delay := data.Singbox.NodeDelays[node]
-		delayStr := "N/A"
-		if delay > 0 {
-			delayStr = fmt.Sprintf("%dms", delay)
+func hiddenGroups(groups []core.ProxyGroup) int {
+	hidden := -len(menuGroups(groups))
+	for _, group := range groups {
+		if len(group.All) > 0 {
+			hidden++
 		}
+	}
+	return hidden
+}
 
-		// Determine style and emoji
+// createModeButtons builds one button per Clash mode, highlighting the current one
+func createModeButtons(data *core.DashboardData) []discordgo.MessageComponent {
+	var row []discordgo.MessageComponent
+	for _, mode := range core.ClashModes {
 		style := discordgo.SecondaryButton
-		emoji := "🌐"
-		if node == data.Singbox.CurrentNode {
+		if mode == data.Singbox.Mode {
 			style = discordgo.SuccessButton
-			emoji = "🟢"
 		}
-
-		button := discordgo.Button{
-			Label:    fmt.Sprintf("%s (%s)", display, delayStr),
+		row = append(row, discordgo.Button{
+			Label:    "Mode: " + mode,
 			Style:    style,
-			CustomID: "node_" + node,
-			Emoji: &discordgo.ComponentEmoji{
-				Name: emoji,
-			},
-		}
+			CustomID: withView("mode_"+mode, data),
+			Disabled: mode == data.Singbox.Mode,
+		})
+	}
+	return row
+}
 
-		currentRow = append(currentRow, button)
+// groupSelectMenu renders a group's members as a select menu; URLTest groups are shown read-only
+func groupSelectMenu(group core.ProxyGroup, data *core.DashboardData) discordgo.SelectMenu {
+	var options []discordgo.SelectMenuOption
+	// A select menu holds at most 25 options
+	for i, node := range group.All {
+		if i >= 25 {
+			break
+		}
 
-		// Discord allows max 5 buttons per row
-		if len(currentRow) == 5 {
-			components = append(components, discordgo.ActionsRow{
-				Components: currentRow,
-			})
-			currentRow = []discordgo.MessageComponent{}
+		// Determine emoji
+		emoji := "🌐"
+		if node == group.Now {
+			emoji = "🟢"
 		}
+
+		options = append(options, discordgo.SelectMenuOption{
+			Label:   fmt.Sprintf("%s (%s)", shortNodeName(node), core.FormatDelay(group.Delays[node])),
+			Value:   node,
+			Default: node == group.Now,
+			Emoji:   &discordgo.ComponentEmoji{Name: emoji},
+		})
+	}
+
+	placeholder := fmt.Sprintf("⚡ %s: chọn node", group.Name)
+	if !group.Switchable() {
+		placeholder = fmt.Sprintf("⚡ %s (tự động)", group.Name)
+	}
+
+	return discordgo.SelectMenu{
+		MenuType:    discordgo.StringSelectMenu,
+		CustomID:    withView("group_"+group.Name, data),
+		Placeholder: placeholder,
+		Options:     options,
+		Disabled:    !group.Switchable(),
+	}
+}
+
+// shortNodeName strips the common WireGuard prefixes from a node name
+func shortNodeName(node string) string {
+	display := strings.ReplaceAll(node, "WG-Solid-", "")
+	return strings.ReplaceAll(display, "WG-", "")
+}
+
+// createControlButtons builds the Proxmox pager, stopped-only toggle, close-connections and refresh buttons
+func createControlButtons(data *core.DashboardData) []discordgo.MessageComponent {
+	_, page, total := proxmoxSection(data)
+	var row []discordgo.MessageComponent
+
+	if total > 1 {
+		row = append(row,
+			discordgo.Button{
+				Label:    "Trước",
+				Style:    discordgo.SecondaryButton,
+				CustomID: pveCustomID(page-1, data.StoppedOnly),
+				Disabled: page == 0,
+				Emoji:    &discordgo.ComponentEmoji{Name: "⬅️"},
+			},
+			discordgo.Button{
+				Label:    "Sau",
+				Style:    discordgo.SecondaryButton,
+				CustomID: pveCustomID(page+1, data.StoppedOnly),
+				Disabled: page >= total-1,
+				Emoji:    &discordgo.ComponentEmoji{Name: "➡️"},
+			},
+		)
 	}
 
-	// Add refresh button to current row or new row
-	refreshButton := discordgo.Button{
+	toggleLabel := "Chỉ máy dừng"
+	if data.StoppedOnly {
+		toggleLabel = "Tất cả máy"
+	}
+	row = append(row, discordgo.Button{
+		Label:    toggleLabel,
+		Style:    discordgo.SecondaryButton,
+		CustomID: pveCustomID(0, !data.StoppedOnly),
+		Emoji:    &discordgo.ComponentEmoji{Name: "⏹️"},
+	})
+
+	row = append(row, discordgo.Button{
+		Label:    "Ngắt kết nối",
+		Style:    discordgo.DangerButton,
+		CustomID: "close_conns",
+		Emoji:    &discordgo.ComponentEmoji{Name: "✂️"},
+	})
+
+	row = append(row, discordgo.Button{
 		Label:    "Refresh",
 		Style:    discordgo.PrimaryButton,
-		CustomID: "refresh",
+		CustomID: withView("refresh", data),
 		Emoji: &discordgo.ComponentEmoji{
 			Name: "🔄",
 		},
+	})
+
+	return row
+}
+
+// pveCustomID encodes the Proxmox view state as "pve_<page>_<all|stopped>"
+func pveCustomID(page int, stoppedOnly bool) string {
+	mode := "all"
+	if stoppedOnly {
+		mode = "stopped"
 	}
+	return fmt.Sprintf("pve_%d_%s", page, mode)
+}
 
-	currentRow = append(currentRow, refreshButton)
+// withView appends the Proxmox view state to a custom ID as "<id>|pve_<page>_<all|stopped>",
+// so refresh, node and mode clicks re-render the page the dashboard was showing
+func withView(customID string, data *core.DashboardData) string {
+	_, page, _ := proxmoxSection(data)
+	return customID + "|" + pveCustomID(page, data.StoppedOnly)
+}
 
-	// Add final row
-	if len(currentRow) > 0 {
-		components = append(components, discordgo.ActionsRow{
-			Components: currentRow,
-		})
+// splitView strips the state added by withView; ok is false for custom IDs without one
+func splitView(customID string) (base string, page int, stoppedOnly bool, ok bool) {
+	cut := strings.LastIndex(customID, "|pve_")
+	if cut < 0 {
+		return customID, 0, false, false
 	}
+	page, stoppedOnly, ok = parsePVECustomID(customID[cut+1:])
+	if !ok {
+		return customID, 0, false, false
+	}
+	return customID[:cut], page, stoppedOnly, true
+}
 
-	return components
+// parsePVECustomID decodes a custom ID produced by pveCustomID
+func parsePVECustomID(customID string) (page int, stoppedOnly bool, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(customID, "pve_"), "_")
+	if len(parts) != 2 {
+		return 0, false, false
+	}
+	page, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false, false
+	}
+	return page, parts[1] == "stopped", true
 }
@@ -2,7 +2,9 @@ package bot
 
 import (
 	"fmt"
+	"strings"
 	"super-bot/core"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -14,26 +16,7 @@ func CreateDashboardEmbed(data *core.DashboardData) *discordgo.MessageEmbed {
 	}
 
 	// Proxmox section
-	pveValue := ""
-	if data.Proxmox.Error != "" {
-		pveValue = "❌ Lỗi kết nối"
-	} else {
-		vmLines := ""
-		for _, vm := range data.Proxmox.VMs {
-			icon := "🖥️"
-			if vm.Type == "lxc" {
-				icon = "📦"
-			}
-			status := "✅"
-			if vm.Status != "running" {
-				status = "❌"
-			}
-			vmLines += fmt.Sprintf("%s %s: %s\n", icon, vm.Name, status)
-		}
-
-		pveValue = fmt.Sprintf("**Node:** `%s`\n**Uptime:** `%s`\n%s",
-			data.Proxmox.Node, data.Proxmox.Uptime, vmLines)
-	}
+	pveValue, _, _ := proxmoxSection(data)
 
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
 		Name:   "🏗️ PROXMOX VE",
@@ -54,6 +37,22 @@ func CreateDashboardEmbed(data *core.DashboardData) *discordgo.MessageEmbed {
 			data.PPPoE.RxSpeed,
 			data.PPPoE.TxSpeed,
 		)
+		if data.PPPoE.PublicIP != "" {
+			mtValue += fmt.Sprintf("\n**WAN IP:** `%s` (từ %s)", data.PPPoE.PublicIP, core.FormatVietnamTime(data.PPPoE.PublicIPChanged))
+		}
+		if mt := data.MikroTik; mt.Version != "" {
+			running, enabled := core.RunningInterfaces(mt.Interfaces)
+			mtValue += fmt.Sprintf("\n**RouterOS:** `%s` (%s) | **Interfaces:** `%d/%d` | **PPP:** `%d`",
+				mt.Version, mt.Board, running, enabled, len(mt.PPPActive))
+		}
+		if len(data.MikroTik.Health) > 0 {
+			mtValue += fmt.Sprintf("\n**Health:** `%s`", core.FormatHealth(data.MikroTik.Health))
+		}
+		if n := len(data.PPPoE.Windows); n > 0 {
+			w := data.PPPoE.Windows[n-1]
+			mtValue += fmt.Sprintf("\n**%s:** TB ↓ `%.2f` ↑ `%.2f` | Đỉnh ↓ `%.2f` ↑ `%.2f` Mbps",
+				w.Window, w.RxAvg, w.TxAvg, w.RxPeak, w.TxPeak)
+		}
 	}
 
 	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
@@ -62,22 +61,180 @@ func CreateDashboardEmbed(data *core.DashboardData) *discordgo.MessageEmbed {
 		Inline: false,
 	})
 
+	// Bandwidth section (only when MONITOR_INTERFACES is set)
+	if len(data.Interfaces) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "📶 BANDWIDTH",
+			Value:  "```\n" + truncate(core.FormatBandwidthTable(data.Interfaces), embedFieldLimit-16) + "```",
+			Inline: false,
+		})
+	}
+
 	// Sing-box section
 	sbValue := ""
 	if data.Singbox.Error != "" {
 		sbValue = fmt.Sprintf("❌ Lỗi: %s", data.Singbox.Error)
 	} else {
-		sbValue = fmt.Sprintf("**Đang chọn:** `%s`\n🕒 Cập nhật lúc: `%s`",
-			data.Singbox.CurrentNode,
-			data.Timestamp,
-		)
+		if data.Singbox.Mode != "" {
+			sbValue += fmt.Sprintf("**Mode:** `%s`\n", data.Singbox.Mode)
+		}
+		for _, group := range data.Singbox.Groups {
+			mode := ""
+			if !group.Switchable() {
+				mode = " (tự động)"
+			}
+			sbValue += fmt.Sprintf("**%s%s:** `%s`\n", group.Name, mode, group.Now)
+		}
+		if hidden := hiddenGroups(data.Singbox.Groups); hidden > 0 {
+			sbValue += fmt.Sprintf("➕ +%d nhóm không có menu chọn node (Discord giới hạn %d menu)\n", hidden, maxGroupMenus)
+		}
+		sbValue += fmt.Sprintf("**Traffic:** `%s`\n", core.FormatTrafficLine(data.Singbox))
+		for _, slot := range data.Singbox.Schedule {
+			sbValue += fmt.Sprintf("⏰ **Lịch:** `%s`\n", core.FormatScheduleSlot(slot))
+		}
+		// One line per group and schedule slot: cap it so the timestamp and the embed survive
+		footer := fmt.Sprintf("🕒 Cập nhật lúc: `%s`", data.Timestamp)
+		sbValue = truncate(sbValue, embedFieldLimit-len(footer)-8) + footer
 	}
 
-	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+	vpnField := &discordgo.MessageEmbedField{
 		Name:   "⚡ VPN EXIT NODE",
 		Value:  sbValue,
 		Inline: false,
-	})
+	}
+
+	// Generic SNMP devices (only when SNMP_DEVICES_FILE is set), in whatever the other fields leave
+	others := append(embed.Fields[:len(embed.Fields):len(embed.Fields)], vpnField)
+	embed.Fields = append(embed.Fields, deviceFields(data.Devices, len(others), embedSize(others))...)
+	embed.Fields = append(embed.Fields, vpnField)
 
 	return embed
 }
+
+// proxmoxSection renders the Proxmox field value for the current page and returns the page position
+func proxmoxSection(data *core.DashboardData) (value string, page, total int) {
+	if data.Proxmox.Error != "" {
+		return "❌ Lỗi kết nối", 0, 1
+	}
+
+	hostLines := ""
+	host := data.Proxmox.Host
+	if host.Error == "" {
+		hostLines = fmt.Sprintf("**CPU:** `%s` | **Load:** `%s`\n**RAM:** `%s/%s (%.0f%%)` | **Swap:** `%s/%s`\n**Root:** `%s/%s` | **PVE:** `%s` | **Kernel:** `%s`\n",
+			host.CPUUsage(), host.LoadAvg,
+			core.FormatBytes(host.MemUsed), core.FormatBytes(host.MemTotal), core.Percent(host.MemUsed, host.MemTotal),
+			core.FormatBytes(host.SwapUsed), core.FormatBytes(host.SwapTotal),
+			core.FormatBytes(host.RootUsed), core.FormatBytes(host.RootTotal),
+			host.PVEVersion, host.Kernel)
+		if host.UpdatesChecked && host.PendingUpdates > 0 {
+			hostLines += fmt.Sprintf("📦 **Updates:** `%d pending`\n", host.PendingUpdates)
+		}
+	}
+
+	header := fmt.Sprintf("**Node:** `%s`\n**Uptime:** `%s`\n%s",
+		data.Proxmox.Node, data.Proxmox.Uptime, hostLines)
+
+	pages := vmPages(data, embedFieldLimit-len(header)-pagerFooterLen)
+	page = core.ClampPage(data.VMPage, len(pages))
+	value = header + strings.Join(pages[page], "\n")
+	if len(pages) > 1 {
+		value += fmt.Sprintf("\n_Trang %d/%d_", page+1, len(pages))
+	}
+	return value, page, len(pages)
+}
+
+const (
+	// Discord rejects embed field values longer than 1024 characters
+	embedFieldLimit = 1024
+	// ...and embeds with more than 25 fields or 6000 characters in total
+	embedMaxFields = 25
+	embedMaxSize   = 6000
+	// Room reserved for the "Trang x/y" line
+	pagerFooterLen = 24
+)
+
+// vmPages renders the (filtered, grouped) guest list and splits it into pages of at most budget bytes
+func vmPages(data *core.DashboardData, budget int) [][]string {
+	var lines []string
+	for _, group := range core.GroupVMs(data.Proxmox.VMs, core.PVEGroupBy, data.StoppedOnly) {
+		if group.Name != "" {
+			lines = append(lines, fmt.Sprintf("__**%s**__", group.Name))
+		}
+		for _, vm := range group.VMs {
+			icon := "🖥️"
+			if vm.Type == "lxc" {
+				icon = "📦"
+			}
+			status := "✅"
+			if vm.Status != "running" {
+				status = "❌"
+			}
+			lines = append(lines, fmt.Sprintf("%s %s: %s", icon, vm.Name, status))
+		}
+	}
+	if len(lines) == 0 && data.StoppedOnly {
+		lines = append(lines, "_Không có máy nào đang dừng_")
+	}
+	return core.PaginateLines(lines, budget)
+}
+
+// deviceFields renders one field per generic SNMP device, keeping the embed within Discord's
+// field count and total size given the used fields and size characters taken by the others.
+// Devices that do not fit are summed up in a last field.
+func deviceFields(devices []core.DeviceInfo, used, size int) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	for n, device := range devices {
+		value := ""
+		if device.Error != "" {
+			value = fmt.Sprintf("❌ Lỗi: %s", device.Error)
+		} else {
+			for _, v := range device.Values {
+				value += fmt.Sprintf("**%s:** `%s`\n", v.Label, v.Value)
+			}
+		}
+		if value == "" {
+			value = "_Không có dữ liệu_"
+		}
+		field := &discordgo.MessageEmbedField{
+			Name:   truncateName("🔌 " + strings.ToUpper(device.Name)),
+			Value:  truncate(value, embedFieldLimit-8),
+			Inline: false,
+		}
+
+		// Unless this is the last device, leave room for the summary field
+		fieldsAfter, sizeAfter := 1, omittedFieldSize
+		if n == len(devices)-1 {
+			fieldsAfter, sizeAfter = 0, 0
+		}
+		fieldSize := embedSize([]*discordgo.MessageEmbedField{field})
+		if used+len(fields)+1+fieldsAfter > embedMaxFields || size+fieldSize+sizeAfter > embedMaxSize {
+			return append(fields, &discordgo.MessageEmbedField{
+				Name:  "🔌 …",
+				Value: fmt.Sprintf("_Còn %d thiết bị không hiển thị được_", len(devices)-n),
+			})
+		}
+		fields = append(fields, field)
+		size += fieldSize
+	}
+	return fields
+}
+
+// Room reserved for the field noting the devices left out
+const omittedFieldSize = 64
+
+// embedSize counts the characters of fields the way Discord does for the 6000-character limit
+func embedSize(fields []*discordgo.MessageEmbedField) int {
+	size := 0
+	for _, field := range fields {
+		size += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return size
+}
+
+// truncateName keeps a field name within Discord's 256-character limit
+func truncateName(name string) string {
+	if utf8.RuneCountInString(name) <= 256 {
+		return name
+	}
+	return string([]rune(name)[:255]) + "…"
+}
@@ -48,9 +48,18 @@ func HandlePingCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 }
 
-// HandleButtonClick handles button interactions (node selection and refresh)
+// HandleButtonClick handles component interactions (node selection and refresh)
 func HandleButtonClick(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	customID := i.MessageComponentData().CustomID
+	customID, page, stoppedOnly, ok := splitView(i.MessageComponentData().CustomID)
+	if !ok {
+		// Dashboards posted before the view state was carried open on the default view
+		page, stoppedOnly = 0, core.PVEOnlyStop
+	}
+
+	// Changing the Clash mode and closing connections are admin-only; refuse before the response is deferred
+	if (strings.HasPrefix(customID, "mode_") || customID == "close_conns") && !requireAdmin(s, i) {
+		return
+	}
 
 	// Defer response
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -69,6 +78,8 @@ func HandleButtonClick(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			})
 			return
 		}
+		data.VMPage = page
+		data.StoppedOnly = stoppedOnly
 
 		embed := CreateDashboardEmbed(data)
 		components := CreateNodeButtons(data)
@@ -85,19 +96,117 @@ func HandleButtonClick(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	// Handle node selection
-	if strings.HasPrefix(customID, "node_") {
-		nodeName := strings.TrimPrefix(customID, "node_")
+	// Close every Sing-box connection so flows reconnect through the selected nodes
+	if customID == "close_conns" {
+		content := "✂️ Đã đóng toàn bộ kết nối"
+		if err := core.CloseAllConnections(ctx); err != nil {
+			content = "❌ Lỗi khi đóng kết nối: " + err.Error()
+		}
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	// Handle Clash mode buttons
+	if strings.HasPrefix(customID, "mode_") {
+		mode := strings.TrimPrefix(customID, "mode_")
+		if err := core.SetClashMode(ctx, mode); err != nil {
+			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: "❌ Lỗi khi đổi mode: " + err.Error(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			})
+			return
+		}
+
+		data, err := core.GetDashboardData(ctx)
+		if err != nil {
+			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: "❌ Lỗi khi lấy dữ liệu",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			})
+			return
+		}
+		data.VMPage = page
+		data.StoppedOnly = stoppedOnly
+
+		embed := CreateDashboardEmbed(data)
+		components := CreateNodeButtons(data)
+
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &components,
+		})
+
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("🧭 Đã chuyển sang mode %s", mode),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	// Handle Proxmox page / stopped-only toggle
+	if strings.HasPrefix(customID, "pve_") {
+		page, stoppedOnly, ok = parsePVECustomID(customID)
+		if !ok {
+			return
+		}
+
+		data, err := core.GetDashboardData(ctx)
+		if err != nil {
+			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: "❌ Lỗi khi lấy dữ liệu",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			})
+			return
+		}
+		data.VMPage = page
+		data.StoppedOnly = stoppedOnly
+
+		embed := CreateDashboardEmbed(data)
+		components := CreateNodeButtons(data)
+
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &components,
+		})
+		return
+	}
+
+	// Handle node selection: select menus carry the group in the custom ID,
+	// buttons on dashboards posted before group support switch the first group
+	if strings.HasPrefix(customID, "group_") || strings.HasPrefix(customID, "node_") {
+		var group, nodeName string
+		if strings.HasPrefix(customID, "group_") {
+			values := i.MessageComponentData().Values
+			if len(values) == 0 {
+				return
+			}
+			group, nodeName = strings.TrimPrefix(customID, "group_"), values[0]
+		} else {
+			info := core.FetchSingboxInfo(ctx)
+			if len(info.Groups) == 0 {
+				s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+					Content: "❌ Lỗi khi chuyển node",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				})
+				return
+			}
+			group, nodeName = info.Groups[0].Name, strings.TrimPrefix(customID, "node_")
+		}
 
 		// Switch node
-		err := core.SwitchNode(nodeName)
+		err := core.SwitchNode(group, nodeName)
 		if err != nil {
 			s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-				Content: "❌ Lỗi khi chuyển node",
+				Content: "❌ Lỗi khi chuyển node: " + err.Error(),
 				Flags:   discordgo.MessageFlagsEphemeral,
 			})
 			return
 		}
+		// The switch succeeded; a close failure is only reported with the confirmation
+		closeErr := core.CloseAfterSwitch(ctx)
 
 		// Get updated data
 		data, err := core.GetDashboardData(ctx)
@@ -108,6 +217,8 @@ func HandleButtonClick(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			})
 			return
 		}
+		data.VMPage = page
+		data.StoppedOnly = stoppedOnly
 
 		embed := CreateDashboardEmbed(data)
 		components := CreateNodeButtons(data)
@@ -117,8 +228,12 @@ func HandleButtonClick(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			Components: &components,
 		})
 
+		content := fmt.Sprintf("✅ %s: đã chọn %s", group, nodeName)
+		if closeErr != nil {
+			content += "\n⚠️ " + closeErr.Error()
+		}
 		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: fmt.Sprintf("✅ Đã chọn %s", nodeName),
+			Content: content,
 			Flags:   discordgo.MessageFlagsEphemeral,
 		})
 	}
@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"super-bot/core"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleInterfacesCommand handles the /interfaces slash command
+func HandleInterfacesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ifaces, err := core.DiscoverInterfaces(ctx)
+	if err != nil {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr("❌ Lỗi SNMP: " + err.Error()),
+		})
+		return
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: stringPtr("📡 **Interfaces**\n```\n" + truncate(core.FormatInterfaceTable(ifaces), 1900) + "```"),
+	})
+}
+
+// truncate cuts s to at most n bytes, marking the cut with an ellipsis line
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// Back off to a rune boundary so the result stays valid UTF-8
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "\n…\n"
+}
+
+// HandleWANCommand handles the admin-only /wan <action> slash command
+func HandleWANCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !requireAdmin(s, i) {
+		return
+	}
+
+	action := optionMap(i.ApplicationCommandData().Options)["action"]
+	if action != "reconnect" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "❓ Hành động không hợp lệ: " + action},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), core.WANReconnectTimeout+30*time.Second)
+	defer cancel()
+
+	header := "🔌 **WAN reconnect**"
+	progress := core.ThrottleProgress(2*time.Second, func(line string) {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr(header + "\n" + line),
+		})
+	})
+
+	result, err := core.ReconnectPPPoE(ctx, progress)
+	if err != nil {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr(header + "\n❌ " + err.Error()),
+		})
+		return
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: stringPtr(header + "\n" + core.FormatWANReconnect(result)),
+	})
+}
+
+// requireAdmin answers non-admins with an ephemeral refusal and reports whether the
+// invoking user is listed in DISCORD_ADMIN_IDS
+func requireAdmin(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	if core.IsDiscordAdmin(interactionUserID(i)) {
+		return true
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "⛔ Chỉ admin mới được dùng lệnh này",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	return false
+}
+
+// interactionUserID returns the invoking user for both guild and DM interactions
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// HandleClientsCommand handles the /clients [search] slash command
+func HandleClientsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	query := optionMap(i.ApplicationCommandData().Options)["search"]
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	leases, err := core.GetDHCPLeases(ctx)
+	if err != nil {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr("❌ Lỗi: " + err.Error()),
+		})
+		return
+	}
+
+	leases = core.SearchLeases(leases, query)
+	if len(leases) == 0 {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr("🔍 Không tìm thấy thiết bị nào"),
+		})
+		return
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: stringPtr(fmt.Sprintf("💻 **Clients** (%d)\n```\n%s```", len(leases), truncate(core.FormatLeaseTable(leases), 1900))),
+	})
+}
@@ -0,0 +1,21 @@
+package bot
+
+import (
+	"log"
+	"super-bot/core"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RegisterNotifier posts core notifications to the configured Discord channel
+func RegisterNotifier(s *discordgo.Session) {
+	if core.DiscordChannelID == "" {
+		log.Println("⚠️  DISCORD_CHANNEL_ID not set, Discord notifications disabled")
+		return
+	}
+	core.RegisterNotifier(func(text string) {
+		if _, err := s.ChannelMessageSend(core.DiscordChannelID, text); err != nil {
+			log.Printf("❌ Discord Error: Failed to send notification: %v", err)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"super-bot/core"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// jobMessage is a channel message that reports a long-running job. Interaction tokens expire
+// after 15 minutes, so migrations and drains report through a normal message instead.
+type jobMessage struct {
+	s         *discordgo.Session
+	i         *discordgo.InteractionCreate
+	channelID string
+	messageID string
+}
+
+// startJobMessage posts content to the interaction's channel and turns the (deferred)
+// interaction response into a link to it, so the job appears once. When the bot cannot post
+// there (e.g. missing permissions) it reports through the interaction response instead.
+func startJobMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content string) *jobMessage {
+	m := &jobMessage{s: s, i: i, channelID: i.ChannelID}
+	msg, err := s.ChannelMessageSend(i.ChannelID, content)
+	if err != nil {
+		log.Println("Error sending job message, using the interaction response:", err)
+		m.Edit(content)
+		return m
+	}
+	m.messageID = msg.ID
+
+	guild := i.GuildID
+	if guild == "" {
+		guild = "@me"
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: stringPtr(fmt.Sprintf("⏩ Tiến trình: https://discord.com/channels/%s/%s/%s", guild, m.channelID, m.messageID)),
+	})
+	return m
+}
+
+// Edit replaces the job message content
+func (m *jobMessage) Edit(content string) {
+	if m.messageID == "" {
+		m.s.InteractionResponseEdit(m.i.Interaction, &discordgo.WebhookEdit{Content: stringPtr(content)})
+		return
+	}
+	m.s.ChannelMessageEdit(m.channelID, m.messageID, content)
+}
+
+// HandleMigrateCommand handles the admin-only /migrate <vm> <target> slash command
+func HandleMigrateCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !requireAdmin(s, i) {
+		return
+	}
+
+	opts := optionMap(i.ApplicationCommandData().Options)
+	vmRef := opts["vm"]
+	target := opts["target"]
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	// MigrateVM bounds the migration itself
+	ctx := context.Background()
+	vm, err := core.FindVM(ctx, vmRef)
+	if err != nil {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr("❌ " + err.Error()),
+		})
+		return
+	}
+
+	header := fmt.Sprintf("🚚 Đang di chuyển **%s** (%d): `%s` → `%s`", vm.Name, vm.VMID, vm.Node, target)
+	job := startJobMessage(s, i, header+"\n⏳ Đang bắt đầu...")
+
+	progress := core.ThrottleProgress(3*time.Second, func(line string) {
+		job.Edit(fmt.Sprintf("%s\n```%s```", header, line))
+	})
+
+	result := core.MigrateVM(ctx, vm, target, progress)
+	job.Edit(core.FormatMigrationSummary([]core.MigrationResult{result}))
+}
+
+// HandleDrainCommand handles the admin-only /drain <node> [target] slash command
+func HandleDrainCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !requireAdmin(s, i) {
+		return
+	}
+
+	opts := optionMap(i.ApplicationCommandData().Options)
+	node := opts["node"]
+	target := opts["target"]
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	header := fmt.Sprintf("🧹 Đang dọn node `%s`", node)
+	job := startJobMessage(s, i, header+"\n⏳ Đang bắt đầu...")
+	progress := core.ThrottleProgress(3*time.Second, func(line string) {
+		job.Edit(fmt.Sprintf("%s\n```%s```", header, line))
+	})
+
+	// Each guest gets its own MigrationTimeout inside DrainNode
+	results, err := core.DrainNode(context.Background(), node, target, progress)
+	if err != nil {
+		if len(results) > 0 {
+			header += "\n" + core.FormatMigrationSummary(results)
+		}
+		job.Edit(header + "\n❌ " + err.Error())
+		return
+	}
+
+	job.Edit(header + "\n" + core.FormatMigrationSummary(results))
+}
+
+// optionMap flattens string slash command options into a name → value map
+func optionMap(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]string {
+	m := make(map[string]string, len(options))
+	for _, opt := range options {
+		m[opt.Name] = fmt.Sprint(opt.Value)
+	}
+	return m
+}
@@ -0,0 +1,295 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"super-bot/core"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleFailoverCommand handles /failover [status|pin|unpin]; pin and unpin are admin only
+func HandleFailoverCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	action := optionMap(i.ApplicationCommandData().Options)["action"]
+	if (action == "pin" || action == "unpin") && !requireAdmin(s, i) {
+		return
+	}
+
+	content := ""
+	switch action {
+	case "pin":
+		core.PinFailover(true)
+		content = "📌 Đã ghim node hiện tại, failover sẽ không tự chuyển\n"
+	case "unpin":
+		core.PinFailover(false)
+		content = "▶️ Đã bỏ ghim, failover tự chuyển trở lại\n"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content + core.FormatFailoverStatus(core.GetFailoverStatus()),
+		},
+	})
+}
+
+// HandleConnectionsCommand handles /connections [search] [action]: lists the top active
+// Sing-box connections, or closes the ones matching search with action=kill
+func HandleConnectionsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := optionMap(i.ApplicationCommandData().Options)
+	query := options["search"]
+	// Listing is open to everyone, closing live flows is admin-only
+	if options["action"] == "kill" && !requireAdmin(s, i) {
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if options["action"] == "kill" {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr(killConnections(ctx, query)),
+		})
+		return
+	}
+
+	snap, err := core.GetConnections(ctx)
+	if err != nil {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr("❌ Lỗi: " + err.Error()),
+		})
+		return
+	}
+
+	conns := core.FilterConnections(snap.Connections, query)
+	if len(conns) == 0 {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr("🔍 Không có kết nối nào"),
+		})
+		return
+	}
+
+	header := fmt.Sprintf("🔗 **Connections** (%d) | Tổng ↑ %s ↓ %s",
+		len(conns), core.FormatBytes(snap.UploadTotal), core.FormatBytes(snap.DownloadTotal))
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: stringPtr(header + "\n```\n" + truncate(core.FormatConnectionsTable(conns, connectionsLimit), 1800) + "```"),
+	})
+}
+
+// connectionsLimit is how many connections /connections lists
+const connectionsLimit = 15
+
+// killConnections closes the connections matching filter and describes the result
+func killConnections(ctx context.Context, filter string) string {
+	if filter == "" {
+		return "⚠️ Cần nhập search để chọn kết nối cần đóng"
+	}
+	closed, err := core.KillConnections(ctx, filter)
+	if err != nil {
+		return fmt.Sprintf("❌ Đã đóng %d kết nối rồi gặp lỗi: %s", closed, err.Error())
+	}
+	return fmt.Sprintf("✂️ Đã đóng %d kết nối khớp \"%s\"", closed, filter)
+}
+
+// HandleModeCommand handles /mode [rule|global|direct]: shows or changes the Clash routing mode
+func HandleModeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Anyone may read the mode, only admins may change it
+	mode := optionMap(i.ApplicationCommandData().Options)["mode"]
+	if mode != "" && !requireAdmin(s, i) {
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: stringPtr(clashMode(ctx, mode)),
+	})
+}
+
+// clashMode switches to mode when given and reports the resulting mode
+func clashMode(ctx context.Context, mode string) string {
+	if mode != "" {
+		if err := core.SetClashMode(ctx, mode); err != nil {
+			return "❌ Lỗi: " + err.Error()
+		}
+	}
+	current, err := core.GetClashMode(ctx)
+	if err != nil {
+		return "❌ Lỗi: " + err.Error()
+	}
+	if mode != "" {
+		return fmt.Sprintf("🧭 Đã chuyển sang mode `%s`", current)
+	}
+	return fmt.Sprintf("🧭 Mode hiện tại: `%s`", current)
+}
+
+// HandleNodesCommand handles /nodes [group]: per-node delay history (last, median, p95, loss)
+func HandleNodesCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: stringPtr(nodesReport(optionMap(i.ApplicationCommandData().Options)["group"])),
+	})
+}
+
+// nodesReport renders the delay history of the matching groups. History comes from the
+// DELAY_HISTORY_INTERVAL tester only, so the command itself runs no test.
+func nodesReport(groupName string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	groups, err := core.ListProxyGroups(ctx)
+	if err != nil {
+		return "❌ Lỗi: " + err.Error()
+	}
+
+	if groupName != "" {
+		group, ok := core.FindProxyGroup(groups, groupName)
+		if !ok {
+			return fmt.Sprintf("🔍 Không có nhóm %s", groupName)
+		}
+		groups = []core.ProxyGroup{group}
+	}
+
+	header := fmt.Sprintf("📶 **Nodes** — test %s, timeout %s", core.SingboxDelayURL, core.SingboxDelayTimeout)
+	return header + "\n```\n" + truncate(core.FormatNodesTable(groups), 1900) + "```"
+}
+
+// HandleSingboxCommand handles the admin-only /singbox outbounds|add|remove command that edits
+// the sing-box config file and reloads it
+func HandleSingboxCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !requireAdmin(s, i) {
+		return
+	}
+
+	options := optionMap(i.ApplicationCommandData().Options)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+
+	if options["action"] == "outbounds" {
+		outbounds, err := core.ListOutbounds()
+		content := "❌ Lỗi: "
+		if err != nil {
+			content += err.Error()
+		} else {
+			content = "📄 **Outbounds** (" + core.SingboxConfigFile + ")\n```\n" + truncate(core.FormatOutboundsTable(outbounds), 1800) + "```"
+		}
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: stringPtr(content)})
+		return
+	}
+
+	selector, tag := options["selector"], options["tag"]
+	if selector == "" || tag == "" {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr("⚠️ Cần nhập selector và tag"),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*core.SingboxReloadTimeout+time.Minute)
+	defer cancel()
+
+	lines := []string{fmt.Sprintf("🛠 **sing-box %s** `%s` ↔ `%s`", options["action"], tag, selector)}
+	progress := func(line string) {
+		lines = append(lines, line)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: stringPtr(strings.Join(lines, "\n")),
+		})
+	}
+
+	var err error
+	if options["action"] == "remove" {
+		err = core.RemoveFromSelector(ctx, selector, tag, progress)
+	} else {
+		err = core.AddToSelector(ctx, selector, tag, options["outbound"], progress)
+	}
+	if err != nil {
+		progress("❌ " + err.Error())
+	}
+}
+
+// HandleVPNLogsCommand handles the admin-only /vpnlogs [level] [minutes] [action]: relays sing-box
+// logs into a thread under the reply until the time runs out or /vpnlogs action:stop is used in
+// the channel
+func HandleVPNLogsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !requireAdmin(s, i) {
+		return
+	}
+
+	options := optionMap(i.ApplicationCommandData().Options)
+	key := "dc:" + i.ChannelID
+
+	if options["action"] == "stop" {
+		content := "ℹ️ Không có stream log nào đang chạy ở kênh này"
+		if core.StopLogStream(key) {
+			content = "⏹ Đã dừng stream log"
+		}
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content},
+		})
+		return
+	}
+
+	if core.LogStreamRunning(key) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "⚠️ Kênh này đang có stream log, dùng `/vpnlogs action:stop` để dừng",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	level := options["level"]
+	if level == "" {
+		level = "info"
+	}
+	minutes, _ := strconv.Atoi(options["minutes"])
+	duration := core.LogStreamLength(minutes)
+
+	header := fmt.Sprintf("📜 **Sing-box logs** (`%s`) trong %s", level, duration)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: header},
+	})
+
+	// Logs go to a thread under the reply; DMs have no threads, so fall back to the channel
+	target := i.ChannelID
+	if msg, err := s.InteractionResponse(i.Interaction); err == nil {
+		thread, err := s.MessageThreadStartComplex(i.ChannelID, msg.ID, &discordgo.ThreadStart{
+			Name:                "vpnlogs-" + level,
+			AutoArchiveDuration: 60,
+		})
+		if err == nil {
+			target = thread.ID
+		}
+	}
+
+	err := core.StartLogStream(key, level, duration, func(lines []string) {
+		s.ChannelMessageSend(target, "```\n"+core.PackLogLines(lines, 1900)+"```")
+	})
+
+	status := "⏹ Đã dừng"
+	if err != nil {
+		status = "❌ Lỗi: " + err.Error()
+	}
+	// The stream can outlive the 15-minute interaction token, so report in the thread/channel
+	s.ChannelMessageSend(target, "📜 Sing-box logs: "+status)
+}
@@ -15,6 +15,9 @@ import (
 func main() {
 	fmt.Println("🚀 Starting Discord Bot...")
 
+	// Resolve SNMP interface names and start sampling counters in the background
+	core.StartSampler()
+
 	// Create Discord session
 	dg, err := discordgo.New("Bot " + core.DiscordToken)
 	if err != nil {
@@ -31,6 +34,28 @@ func main() {
 				bot.HandleStatusCommand(s, i)
 			case "ping":
 				bot.HandlePingCommand(s, i)
+			case "clients":
+				go bot.HandleClientsCommand(s, i)
+			case "wan":
+				go bot.HandleWANCommand(s, i)
+			case "interfaces":
+				go bot.HandleInterfacesCommand(s, i)
+			case "migrate":
+				go bot.HandleMigrateCommand(s, i)
+			case "drain":
+				go bot.HandleDrainCommand(s, i)
+			case "failover":
+				bot.HandleFailoverCommand(s, i)
+			case "connections":
+				go bot.HandleConnectionsCommand(s, i)
+			case "mode":
+				go bot.HandleModeCommand(s, i)
+			case "nodes":
+				go bot.HandleNodesCommand(s, i)
+			case "singbox":
+				go bot.HandleSingboxCommand(s, i)
+			case "vpnlogs":
+				go bot.HandleVPNLogsCommand(s, i)
 			}
 		case discordgo.InteractionMessageComponent:
 			// Handle button clicks
@@ -61,6 +86,149 @@ func main() {
 			Name:        "ping",
 			Description: "Check bot latency",
 		},
+		{
+			Name:        "clients",
+			Description: "List DHCP leases / connected clients",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "search", Description: "Filter by name, MAC or IP"},
+			},
+		},
+		{
+			Name:        "wan",
+			Description: "WAN actions (admin only)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "Action to run",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "reconnect", Value: "reconnect"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "interfaces",
+			Description: "List MikroTik interfaces and SNMP indexes",
+		},
+		{
+			Name:        "migrate",
+			Description: "Migrate a Proxmox guest to another node",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "vm", Description: "VM name or VMID", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "target", Description: "Target node", Required: true},
+			},
+		},
+		{
+			Name:        "drain",
+			Description: "Migrate all guests off a Proxmox node",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "node", Description: "Node to drain", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "target", Description: "Target node (default: spread across others)"},
+			},
+		},
+		{
+			Name:        "failover",
+			Description: "Show or pin the VPN failover watchdog",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "pin stops automatic switching, unpin resumes it",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "status", Value: "status"},
+						{Name: "pin", Value: "pin"},
+						{Name: "unpin", Value: "unpin"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "connections",
+			Description: "List or close active Sing-box connections",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "search", Description: "Filter by host, IP, rule or chain"},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "kill closes the connections matching search",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "list", Value: "list"},
+						{Name: "kill", Value: "kill"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "mode",
+			Description: "Show or change the Sing-box routing mode",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "New mode",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "rule", Value: "rule"},
+						{Name: "global", Value: "global"},
+						{Name: "direct", Value: "direct"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "nodes",
+			Description: "VPN node delay history (median, p95, loss)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "group", Description: "Only this selector group"},
+			},
+		},
+		{
+			Name:        "singbox",
+			Description: "Edit sing-box selectors in the config file (admin only)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "Action to run",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "outbounds", Value: "outbounds"},
+						{Name: "add", Value: "add"},
+						{Name: "remove", Value: "remove"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "selector", Description: "Selector tag, e.g. ExitNode"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "tag", Description: "Outbound tag to add or remove"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "outbound", Description: "JSON of a new outbound to create (add only)"},
+			},
+		},
+		{
+			Name:        "vpnlogs",
+			Description: "Stream sing-box logs into a thread",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "level",
+					Description: "Minimum log level (default info)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "debug", Value: "debug"},
+						{Name: "info", Value: "info"},
+						{Name: "warning", Value: "warning"},
+						{Name: "error", Value: "error"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "minutes", Description: "How long to stream (default 5)"},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "Stop the stream running in this channel",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "stop", Value: "stop"},
+					},
+				},
+			},
+		},
 	}
 
 	for _, cmd := range commands {
@@ -70,6 +238,16 @@ func main() {
 		}
 	}
 
+	// Route background notifications to the channel, then start watchers that use them
+	bot.RegisterNotifier(dg)
+	core.StartPublicIPWatcher()
+	core.StartAlertEngine()
+	core.StartClientWatcher()
+	core.StartTrapReceiver()
+	core.StartFailoverWatchdog()
+	core.StartDelayHistory()
+	core.StartNodeSchedule()
+
 	fmt.Println("✅ Discord Bot is running. Press CTRL+C to exit.")
 
 	// Wait for interrupt signal
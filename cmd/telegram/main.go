@@ -21,6 +21,9 @@ func main() {
 	bot.Debug = false
 	log.Printf("🤖 Telegram Bot authorized on account %s", bot.Self.UserName)
 
+	// Resolve SNMP interface names and start sampling counters in the background
+	core.StartSampler()
+
 	// Update Config
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
@@ -43,6 +46,28 @@ func main() {
 				switch update.Message.Command() {
 				case "status":
 					go telegram.HandleStatusCommand(bot, update)
+				case "clients":
+					go telegram.HandleClientsCommand(bot, update)
+				case "wan":
+					go telegram.HandleWANCommand(bot, update)
+				case "interfaces":
+					go telegram.HandleInterfacesCommand(bot, update)
+				case "migrate":
+					go telegram.HandleMigrateCommand(bot, update)
+				case "drain":
+					go telegram.HandleDrainCommand(bot, update)
+				case "failover":
+					go telegram.HandleFailoverCommand(bot, update)
+				case "connections":
+					go telegram.HandleConnectionsCommand(bot, update)
+				case "mode":
+					go telegram.HandleModeCommand(bot, update)
+				case "nodes":
+					go telegram.HandleNodesCommand(bot, update)
+				case "singbox":
+					go telegram.HandleSingboxCommand(bot, update)
+				case "vpnlogs":
+					go telegram.HandleVPNLogsCommand(bot, update)
 				}
 			}
 
@@ -53,6 +78,16 @@ func main() {
 		}
 	}()
 
+	// Route background notifications to the chat, then start watchers that use them
+	telegram.RegisterNotifier(bot)
+	core.StartPublicIPWatcher()
+	core.StartAlertEngine()
+	core.StartClientWatcher()
+	core.StartTrapReceiver()
+	core.StartFailoverWatchdog()
+	core.StartDelayHistory()
+	core.StartNodeSchedule()
+
 	log.Println("✅ Telegram Bot is polling...")
 
 	// Wait for interrupt signal
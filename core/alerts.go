@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertRule is a threshold on one metric, e.g. "mikrotik.cpu-temperature > 75"
+type AlertRule struct {
+	Metric    string
+	Op        string // ">", ">=", "<", "<="
+	Threshold float64
+}
+
+// MetricSource returns current metric values keyed by name (without the source prefix)
+type MetricSource func(ctx context.Context) (map[string]float64, error)
+
+// metricSources are queried by the alert engine on every evaluation
+var metricSources = struct {
+	sync.Mutex
+	byPrefix map[string]MetricSource
+}{byPrefix: make(map[string]MetricSource)}
+
+// RegisterMetricSource exposes a collector's values to the alert engine as <prefix>.<name>
+func RegisterMetricSource(prefix string, source MetricSource) {
+	metricSources.Lock()
+	metricSources.byPrefix[prefix] = source
+	metricSources.Unlock()
+}
+
+// String renders the rule as written in ALERT_RULES
+func (r AlertRule) String() string {
+	return fmt.Sprintf("%s %s %s", r.Metric, r.Op, strconv.FormatFloat(r.Threshold, 'f', -1, 64))
+}
+
+// Firing reports whether value breaches the rule
+func (r AlertRule) Firing(value float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	}
+	return false
+}
+
+// ParseAlertRules parses "metric>value;metric<value" (';' or newline separated)
+func ParseAlertRules(s string) ([]AlertRule, error) {
+	var rules []AlertRule
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' }) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		// Check two-character operators first so ">=" isn't split at ">"
+		var rule AlertRule
+		for _, op := range []string{">=", "<=", ">", "<"} {
+			if i := strings.Index(item, op); i > 0 {
+				threshold, err := strconv.ParseFloat(strings.TrimSpace(item[i+len(op):]), 64)
+				if err != nil {
+					return nil, fmt.Errorf("alert rule %q: %w", item, err)
+				}
+				rule = AlertRule{Metric: strings.TrimSpace(item[:i]), Op: op, Threshold: threshold}
+				break
+			}
+		}
+		if rule.Op == "" {
+			return nil, fmt.Errorf("alert rule %q: missing comparison operator", item)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// CollectMetrics queries every registered source; failing sources are skipped
+func CollectMetrics(ctx context.Context) map[string]float64 {
+	metricSources.Lock()
+	sources := make(map[string]MetricSource, len(metricSources.byPrefix))
+	for prefix, source := range metricSources.byPrefix {
+		sources[prefix] = source
+	}
+	metricSources.Unlock()
+
+	metrics := make(map[string]float64)
+	for prefix, source := range sources {
+		values, err := source(ctx)
+		if err != nil {
+			log.Printf("⚠️  Alert source %s failed: %v", prefix, err)
+			continue
+		}
+		for name, v := range values {
+			metrics[prefix+"."+name] = v
+		}
+	}
+	return metrics
+}
+
+// StartAlertEngine evaluates ALERT_RULES every ALERT_INTERVAL and notifies when a rule
+// starts or stops firing. It does nothing when no rules are configured.
+func StartAlertEngine() {
+	if len(AlertRules) == 0 {
+		return
+	}
+
+	go func() {
+		firing := make(map[string]bool)
+		ticker := time.NewTicker(AlertInterval)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			metrics := CollectMetrics(ctx)
+			cancel()
+
+			for _, rule := range AlertRules {
+				value, ok := metrics[rule.Metric]
+				if !ok {
+					continue
+				}
+				key := rule.String()
+				now := rule.Firing(value)
+				switch {
+				case now && !firing[key]:
+					Notify(fmt.Sprintf("🚨 Cảnh báo: %s (hiện tại %s)", key, strconv.FormatFloat(value, 'f', -1, 64)))
+				case !now && firing[key]:
+					Notify(fmt.Sprintf("✅ Đã hết cảnh báo: %s (hiện tại %s)", key, strconv.FormatFloat(value, 'f', -1, 64)))
+				}
+				firing[key] = now
+			}
+		}
+	}()
+}
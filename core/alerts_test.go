@@ -0,0 +1,51 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAlertRules(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []AlertRule
+	}{
+		{"", nil},
+		{" ; \n ", nil},
+		{"mikrotik.cpu-temperature > 75", []AlertRule{{Metric: "mikrotik.cpu-temperature", Op: ">", Threshold: 75}}},
+		{"a>=1;b<=2", []AlertRule{{Metric: "a", Op: ">=", Threshold: 1}, {Metric: "b", Op: "<=", Threshold: 2}}},
+		{"a<-3.5\nb>0", []AlertRule{{Metric: "a", Op: "<", Threshold: -3.5}, {Metric: "b", Op: ">", Threshold: 0}}},
+		{"  pve.cpu >= 90 ;", []AlertRule{{Metric: "pve.cpu", Op: ">=", Threshold: 90}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAlertRules(tt.input)
+		if err != nil {
+			t.Errorf("ParseAlertRules(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAlertRules(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseAlertRulesErrors(t *testing.T) {
+	for _, input := range []string{
+		"cpu",        // no operator
+		"cpu = 5",    // unsupported operator
+		"> 5",        // no metric
+		"cpu > high", // threshold is not a number
+		"a>1;b",      // one bad rule rejects the list
+	} {
+		if rules, err := ParseAlertRules(input); err == nil {
+			t.Errorf("ParseAlertRules(%q) = %+v, expected an error", input, rules)
+		}
+	}
+}
+
+func TestAlertRuleString(t *testing.T) {
+	rule := AlertRule{Metric: "mikrotik.cpu-load", Op: ">=", Threshold: 80.5}
+	if got := rule.String(); got != "mikrotik.cpu-load >= 80.5" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,13 @@
+package core
+
+import "strconv"
+
+// IsDiscordAdmin reports whether a Discord user ID is listed in DISCORD_ADMIN_IDS
+func IsDiscordAdmin(userID string) bool {
+	return contains(DiscordAdminIDs, userID)
+}
+
+// IsTelegramAdmin reports whether a Telegram user ID is listed in TELEGRAM_ADMIN_IDS
+func IsTelegramAdmin(userID int64) bool {
+	return contains(TelegramAdminIDs, strconv.FormatInt(userID, 10))
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IF-MIB columns sampled per monitored interface
+const (
+	oidIfHCInOctets  = "1.3.6.1.2.1.31.1.1.1.6"
+	oidIfHCOutOctets = "1.3.6.1.2.1.31.1.1.1.10"
+	oidIfInDiscards  = "1.3.6.1.2.1.2.2.1.13"
+	oidIfInErrors    = "1.3.6.1.2.1.2.2.1.14"
+	oidIfOutDiscards = "1.3.6.1.2.1.2.2.1.19"
+	oidIfOutErrors   = "1.3.6.1.2.1.2.2.1.20"
+)
+
+// historySize is the number of samples kept per interface (one per minute, 24 hours)
+const historySize = 1440
+
+// BandwidthSample is one recorded throughput reading
+type BandwidthSample struct {
+	Time    time.Time
+	RxSpeed float64 // Mbps
+	TxSpeed float64 // Mbps
+}
+
+// bandwidthHistory keeps the most recent samples per interface name
+var bandwidthHistory = struct {
+	sync.Mutex
+	samples map[string][]BandwidthSample
+}{samples: make(map[string][]BandwidthSample)}
+
+// GetInterfaceStats reports rate, errors and discards for every configured interface from the sampler
+func GetInterfaceStats(ctx context.Context, resultChan chan<- []InterfaceStats) {
+	defer close(resultChan)
+
+	if len(MonitorInterfaces) == 0 {
+		resultChan <- nil
+		return
+	}
+
+	stats := make([]InterfaceStats, 0, len(MonitorInterfaces))
+	for _, name := range MonitorInterfaces {
+		windows, err := GetRates(name)
+		if err != nil {
+			stats = append(stats, InterfaceStats{Name: name, Error: err.Error()})
+			continue
+		}
+
+		current := pickWindow(windows, DashboardRateWindow)
+		// Errors and discards over the longest window: the shorter ones are mostly zero
+		longest := windows[len(windows)-1]
+		stats = append(stats, InterfaceStats{
+			Name:        name,
+			RxSpeed:     current.RxAvg,
+			TxSpeed:     current.TxAvg,
+			InErrors:    longest.Errors[0],
+			OutErrors:   longest.Errors[1],
+			InDiscards:  longest.Errors[2],
+			OutDiscards: longest.Errors[3],
+			ErrorWindow: longest.Window,
+			Windows:     windows,
+			History:     historyWindow(BandwidthHistory(name)),
+		})
+	}
+
+	resultChan <- stats
+}
+
+// RecordBandwidth appends a throughput sample to the interface history
+func RecordBandwidth(name string, rx, tx float64) {
+	bandwidthHistory.Lock()
+	defer bandwidthHistory.Unlock()
+
+	samples := append(bandwidthHistory.samples[name], BandwidthSample{Time: time.Now(), RxSpeed: rx, TxSpeed: tx})
+	if len(samples) > historySize {
+		samples = samples[len(samples)-historySize:]
+	}
+	bandwidthHistory.samples[name] = samples
+}
+
+// BandwidthHistory returns a copy of the recorded samples for an interface, oldest first
+func BandwidthHistory(name string) []BandwidthSample {
+	bandwidthHistory.Lock()
+	defer bandwidthHistory.Unlock()
+
+	return append([]BandwidthSample(nil), bandwidthHistory.samples[name]...)
+}
+
+// historyWindow sums up the per-minute history: the average over the span it covers and the
+// busiest minute. Window is zero when nothing has been recorded yet.
+func historyWindow(samples []BandwidthSample) RateWindow {
+	if len(samples) == 0 {
+		return RateWindow{}
+	}
+	w := RateWindow{Window: time.Since(samples[0].Time).Round(time.Minute) + time.Minute}
+	for _, s := range samples {
+		w.RxAvg += s.RxSpeed
+		w.TxAvg += s.TxSpeed
+		w.RxPeak = max(w.RxPeak, s.RxSpeed)
+		w.TxPeak = max(w.TxPeak, s.TxSpeed)
+	}
+	w.RxAvg /= float64(len(samples))
+	w.TxAvg /= float64(len(samples))
+	return w
+}
+
+// FormatBandwidthTable renders interface stats as a fixed-width table for code blocks.
+// Errors and discards are the ones counted inside the longest rate window.
+func FormatBandwidthTable(stats []InterfaceStats) string {
+	span := ""
+	for _, s := range stats {
+		if s.ErrorWindow > 0 {
+			span = "/" + shortSpan(s.ErrorWindow)
+			break
+		}
+	}
+	line := fmt.Sprintf("%-12s %8s %8s %7s %7s\n", "IF", "↓Mbps", "↑Mbps", "ERR"+span, "DROP"+span)
+	for _, s := range stats {
+		if s.Error != "" {
+			line += fmt.Sprintf("%-12s ❌ %s\n", s.Name, s.Error)
+			continue
+		}
+		line += fmt.Sprintf("%-12s %8.2f %8.2f %7d %7d\n", s.Name, s.RxSpeed, s.TxSpeed,
+			s.InErrors+s.OutErrors, s.InDiscards+s.OutDiscards)
+	}
+
+	// Averages and peaks of the per-minute history, up to the last 24 hours
+	history := ""
+	for _, s := range stats {
+		if s.Error != "" || s.History.Window == 0 {
+			continue
+		}
+		history += fmt.Sprintf("%-12s %6.2f %6.2f %6.2f %6.2f %s\n", s.Name,
+			s.History.RxAvg, s.History.TxAvg, s.History.RxPeak, s.History.TxPeak, shortSpan(s.History.Window))
+	}
+	if history != "" {
+		line += fmt.Sprintf("\n%-12s %6s %6s %6s %6s\n", "IF", "TB↓", "TB↑", "Đỉnh↓", "Đỉnh↑") + history
+	}
+	return line
+}
+
+// shortSpan renders a span as whole hours, or minutes below one hour, or seconds below one minute
+func shortSpan(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh", int(d.Hours()))
+}
@@ -1,8 +1,13 @@
 package core
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,24 +15,97 @@ import (
 // Configuration variables
 var (
 	// Telegram Config
-	TelegramToken  string
-	TelegramChatID string
+	TelegramToken    string
+	TelegramChatID   string
+	TelegramAdminIDs []string // User IDs allowed to run admin actions
 
 	// Discord Config
 	DiscordToken     string
 	DiscordChannelID string
+	DiscordAdminIDs  []string // User IDs allowed to run admin actions
 
 	// Infrastructure Config
 	PVEHost       string
 	PVEUser       string
 	PVETokenName  string
 	PVETokenValue string
+	PVEPort       string
+	PVEURL        string // Base API URL, e.g. https://pve.lan:8006/api2/json
+	PVETLS        TLSOptions
+	PVEFilter     VMFilter
+	PVEGroupBy    string // "", "tag" or "pool"
+	PVEOnlyStop   bool   // Default to showing only stopped guests
 
-	MikroTikIP    string
-	SNMPCommunity string
-	PPPoEIndex    string
+	MikroTikIP        string
+	SNMPCommunity     string
+	MikroTikSNMP      SNMPTarget
+	MikroTikAPI       RouterOSConfig
+	MikroTikCollector string   // "snmp" (default), "api" or "both"
+	PPPoEIndex        string   // Deprecated: use PPPoEInterface
+	PPPoEInterface    string   // Interface name (or numeric ifIndex) resolved via IF-MIB
+	MonitorInterfaces []string // Interfaces shown in the bandwidth table
 
-	SingboxAPI string
+	WANReconnectTimeout time.Duration // Max wait for PPPoE to come back after /wan reconnect
+	PublicIPSource      string        // "router" or an HTTP URL returning the address as text
+	PublicIPInterval    time.Duration
+
+	KnownMACs            []string // MACs that never trigger "unknown client" notifications
+	NotifyUnknownClients bool
+	ClientWatchInterval  time.Duration
+
+	SampleInterval      time.Duration   // SNMP counter polling interval
+	RateWindows         []time.Duration // Averaging windows, e.g. 1s,10s,1m,5m
+	DashboardRateWindow time.Duration   // Window shown as the current rate
+
+	SingboxAPI           string
+	SingboxSecret        string // clash_api.secret, sent as a Bearer token
+	SingboxTLS           TLSOptions
+	SingboxGroups        []string // Groups shown with a node picker; empty = all selector/urltest groups
+	SingboxHiddenNodes   []string // Group members never offered, default REJECT,GLOBAL
+	SingboxCloseOnSwitch bool     // Close all connections after a node switch
+	SingboxDelayURL      string   // URL fetched through each node by the delay test
+	SingboxDelayTimeout  time.Duration
+	DelayHistorySize     int           // Delay samples kept per node
+	DelayHistoryInterval time.Duration // Background delay test period; 0 disables it
+
+	// Sing-box config file management (admin only)
+	SingboxConfigFile    string // Path of the running config.json; empty disables /singbox edits
+	SingboxReloadCmd     string // Shell command that reloads sing-box after a config change
+	SingboxCheckCmd      string // Optional validator, "{file}" is replaced by the candidate config
+	SingboxReloadTimeout time.Duration
+	SingboxConfigBackups int // Timestamped backups kept next to the config file
+
+	// /vpnlogs streaming
+	LogStreamInterval    time.Duration // Minimum time between two relayed batches
+	LogStreamDuration    time.Duration // Default stream length
+	LogStreamMaxDuration time.Duration // Upper bound for the requested length
+
+	// VPN failover watchdog
+	FailoverEnabled   bool
+	FailoverGroup     string // Selector group watched, default: first SINGBOX_GROUPS entry or ExitNode
+	FailoverInterval  time.Duration
+	FailoverFailures  int           // Consecutive bad checks before switching
+	FailoverMaxDelay  time.Duration // Delay above which a check counts as bad; 0 = only errors
+	FailoverPolicy    string        // "lowest" (default), "preferred" or "sticky"
+	FailoverPreferred []string      // Node order for the "preferred" policy
+	FailoverMargin    time.Duration // "sticky": a slow node is only left for one faster by this much
+	FailoverHold      time.Duration // "sticky": minimum time between automatic switches
+
+	// Scheduled exit node switching
+	NodeSchedule              []ScheduleEntry
+	NodeScheduleManualSuspend bool // A manual switch holds the group until its next slot
+
+	// Alerts
+	AlertRules    []AlertRule
+	AlertInterval time.Duration
+
+	// SNMP trap receiver
+	TrapListen   string // UDP address, e.g. ":162"; empty disables the receiver
+	TrapTarget   SNMPTarget
+	TrapEngineID string // Sender engine ID (hex) for SNMPv3 traps
+
+	// Generic SNMP devices (UPS, switches, printers...)
+	SNMPDevices []SNMPDeviceConfig
 )
 
 func init() {
@@ -39,27 +117,199 @@ func init() {
 	// Telegram
 	TelegramToken = os.Getenv("TELEGRAM_TOKEN")
 	TelegramChatID = os.Getenv("TELEGRAM_CHAT_ID")
+	TelegramAdminIDs = splitList(os.Getenv("TELEGRAM_ADMIN_IDS"))
 
 	// Discord
 	DiscordToken = os.Getenv("DISCORD_TOKEN")
 	DiscordChannelID = os.Getenv("DISCORD_CHANNEL_ID")
+	DiscordAdminIDs = splitList(os.Getenv("DISCORD_ADMIN_IDS"))
 
 	// Proxmox
 	PVEHost = os.Getenv("PVE_IP")
 	PVEUser = os.Getenv("PVE_USER")
 	PVETokenName = os.Getenv("PVE_TOKEN_NAME")
 	PVETokenValue = os.Getenv("PVE_TOKEN_VALUE")
+	PVEPort = os.Getenv("PVE_PORT")
+	PVEURL = os.Getenv("PVE_URL")
+	PVETLS = LoadTLSOptions("PVE")
+	PVEFilter = VMFilter{
+		IncludeTags:  splitList(os.Getenv("PVE_INCLUDE_TAGS")),
+		ExcludeTags:  splitList(os.Getenv("PVE_EXCLUDE_TAGS")),
+		IncludePools: splitList(os.Getenv("PVE_INCLUDE_POOLS")),
+		ExcludePools: splitList(os.Getenv("PVE_EXCLUDE_POOLS")),
+		IncludeNames: splitList(os.Getenv("PVE_INCLUDE_NAMES")),
+		ExcludeNames: splitList(os.Getenv("PVE_EXCLUDE_NAMES")),
+	}
+	PVEGroupBy = strings.ToLower(os.Getenv("PVE_GROUP_BY"))
+	PVEOnlyStop = parseBool(os.Getenv("PVE_ONLY_STOPPED"))
 
 	// MikroTik
 	MikroTikIP = os.Getenv("MIKROTIK_IP")
 	SNMPCommunity = os.Getenv("SNMP_COMMUNITY")
+	MikroTikSNMP = LoadSNMPTarget("MIKROTIK", MikroTikIP, SNMPCommunity)
+	MikroTikAPI = RouterOSConfig{
+		Host:     MikroTikIP,
+		Port:     os.Getenv("MIKROTIK_API_PORT"),
+		UseTLS:   parseBool(os.Getenv("MIKROTIK_API_TLS")),
+		TLS:      LoadTLSOptions("MIKROTIK_API"),
+		User:     os.Getenv("MIKROTIK_API_USER"),
+		Password: os.Getenv("MIKROTIK_API_PASS"),
+		Timeout:  parseDuration(os.Getenv("MIKROTIK_API_TIMEOUT"), 5*time.Second),
+	}
+	MikroTikCollector = strings.ToLower(os.Getenv("MIKROTIK_COLLECTOR"))
+	WANReconnectTimeout = parseDuration(os.Getenv("WAN_RECONNECT_TIMEOUT"), 60*time.Second)
+	PublicIPSource = os.Getenv("PUBLIC_IP_SOURCE")
+	PublicIPInterval = parseDuration(os.Getenv("PUBLIC_IP_INTERVAL"), time.Minute)
+	for _, mac := range splitList(os.Getenv("KNOWN_MACS")) {
+		KnownMACs = append(KnownMACs, strings.ToUpper(strings.ReplaceAll(mac, "-", ":")))
+	}
+	NotifyUnknownClients = parseBool(os.Getenv("NOTIFY_UNKNOWN_CLIENTS"))
+	ClientWatchInterval = parseDuration(os.Getenv("CLIENT_WATCH_INTERVAL"), time.Minute)
 	PPPoEIndex = os.Getenv("PPPOE_INDEX")
+	PPPoEInterface = os.Getenv("PPPOE_INTERFACE")
+	MonitorInterfaces = splitList(os.Getenv("MONITOR_INTERFACES"))
+	SampleInterval = parseDuration(os.Getenv("SAMPLE_INTERVAL"), time.Second)
+	DashboardRateWindow = parseDuration(os.Getenv("DASHBOARD_RATE_WINDOW"), 10*time.Second)
+	for _, w := range splitList(os.Getenv("RATE_WINDOWS")) {
+		if d, err := time.ParseDuration(w); err == nil && d > 0 {
+			RateWindows = append(RateWindows, d)
+		}
+	}
+	if len(RateWindows) == 0 {
+		RateWindows = []time.Duration{time.Second, 10 * time.Second, time.Minute, 5 * time.Minute}
+	}
+	// Callers rely on shortest-first order and treat the last window as the longest
+	slices.Sort(RateWindows)
+	RateWindows = slices.Compact(RateWindows)
 
 	// Sing-box
 	SingboxAPI = os.Getenv("SINGBOX_API")
+	SingboxSecret = os.Getenv("SINGBOX_SECRET")
+	SingboxTLS = LoadTLSOptions("SINGBOX")
+	SingboxGroups = splitList(os.Getenv("SINGBOX_GROUPS"))
+	SingboxHiddenNodes = splitList(os.Getenv("SINGBOX_HIDE_NODES"))
+	SingboxCloseOnSwitch = parseBool(os.Getenv("SINGBOX_CLOSE_ON_SWITCH"))
+	SingboxDelayURL = os.Getenv("SINGBOX_DELAY_URL")
+	SingboxDelayTimeout = parseDuration(os.Getenv("SINGBOX_DELAY_TIMEOUT"), 2*time.Second)
+	DelayHistorySize = parseInt(os.Getenv("DELAY_HISTORY_SIZE"), 120)
+	DelayHistoryInterval = parseDuration(os.Getenv("DELAY_HISTORY_INTERVAL"), time.Minute)
+	if os.Getenv("DELAY_HISTORY_INTERVAL") == "0" {
+		DelayHistoryInterval = 0
+	}
+	SingboxConfigFile = os.Getenv("SINGBOX_CONFIG_FILE")
+	SingboxReloadCmd = os.Getenv("SINGBOX_RELOAD_CMD")
+	SingboxCheckCmd = os.Getenv("SINGBOX_CHECK_CMD")
+	SingboxReloadTimeout = parseDuration(os.Getenv("SINGBOX_RELOAD_TIMEOUT"), 30*time.Second)
+	SingboxConfigBackups = parseInt(os.Getenv("SINGBOX_CONFIG_BACKUPS"), 5)
+	LogStreamInterval = parseDuration(os.Getenv("LOG_STREAM_INTERVAL"), 3*time.Second)
+	LogStreamDuration = parseDuration(os.Getenv("LOG_STREAM_DURATION"), 5*time.Minute)
+	LogStreamMaxDuration = parseDuration(os.Getenv("LOG_STREAM_MAX_DURATION"), 30*time.Minute)
+
+	// VPN failover watchdog
+	FailoverEnabled = parseBool(os.Getenv("FAILOVER_ENABLED"))
+	FailoverGroup = os.Getenv("FAILOVER_GROUP")
+	FailoverInterval = parseDuration(os.Getenv("FAILOVER_INTERVAL"), 30*time.Second)
+	FailoverFailures = parseInt(os.Getenv("FAILOVER_FAILURES"), 3)
+	FailoverMaxDelay = parseDuration(os.Getenv("FAILOVER_MAX_DELAY"), 0)
+	FailoverPolicy = strings.ToLower(os.Getenv("FAILOVER_POLICY"))
+	FailoverPreferred = splitList(os.Getenv("FAILOVER_PREFERRED"))
+	FailoverMargin = parseDuration(os.Getenv("FAILOVER_MARGIN"), 100*time.Millisecond)
+	FailoverHold = parseDuration(os.Getenv("FAILOVER_HOLD"), 10*time.Minute)
+
+	// Alerts
+	rules, err := ParseAlertRules(os.Getenv("ALERT_RULES"))
+	if err != nil {
+		log.Printf("⚠️  Ignoring ALERT_RULES: %v", err)
+	}
+	AlertRules = rules
+	AlertInterval = parseDuration(os.Getenv("ALERT_INTERVAL"), time.Minute)
+
+	// SNMP trap receiver (community defaults to the polling community)
+	TrapListen = os.Getenv("TRAP_LISTEN")
+	TrapTarget = LoadSNMPTarget("TRAP", "", SNMPCommunity)
+	TrapEngineID = os.Getenv("TRAP_SNMP_ENGINE_ID")
+
+	// Generic SNMP devices
+	if path := os.Getenv("SNMP_DEVICES_FILE"); path != "" {
+		devices, err := LoadSNMPDevices(path)
+		if err != nil {
+			log.Printf("⚠️  Ignoring SNMP_DEVICES_FILE: %v", err)
+		}
+		SNMPDevices = devices
+	}
 
 	// Set defaults if needed (optional)
 	if SingboxAPI == "" {
 		SingboxAPI = "http://127.0.0.1:9090"
 	}
+	SingboxAPI = strings.TrimSuffix(SingboxAPI, "/")
+	if SingboxReloadCmd == "" {
+		SingboxReloadCmd = "systemctl restart sing-box"
+	}
+	if SingboxDelayURL == "" {
+		SingboxDelayURL = "https://dns.google/"
+	}
+	if len(SingboxHiddenNodes) == 0 {
+		SingboxHiddenNodes = []string{"REJECT", "GLOBAL"}
+	}
+	if MikroTikAPI.Port == "" {
+		MikroTikAPI.Port = "8728"
+		if MikroTikAPI.UseTLS {
+			MikroTikAPI.Port = "8729"
+		}
+	}
+	if FailoverGroup == "" {
+		FailoverGroup = "ExitNode"
+		if len(SingboxGroups) > 0 {
+			FailoverGroup = SingboxGroups[0]
+		}
+	}
+	if FailoverPolicy != "preferred" && FailoverPolicy != "sticky" {
+		FailoverPolicy = "lowest"
+	}
+
+	// Scheduled switching (entries without a group use the failover group default above)
+	entries, err := ParseNodeSchedule(os.Getenv("NODE_SCHEDULE"), FailoverGroup)
+	if err != nil {
+		log.Printf("⚠️  Ignoring NODE_SCHEDULE: %v", err)
+	}
+	NodeSchedule = entries
+	NodeScheduleManualSuspend = os.Getenv("NODE_SCHEDULE_MANUAL_SUSPEND") != "false"
+
+	if PublicIPSource == "" {
+		PublicIPSource = "router"
+	}
+	if PPPoEInterface == "" {
+		PPPoEInterface = PPPoEIndex
+	}
+	if PPPoEInterface == "" {
+		PPPoEInterface = "pppoe-out1"
+	}
+	if PVEPort == "" {
+		PVEPort = "8006"
+	}
+	if PVEURL == "" {
+		PVEURL = fmt.Sprintf("https://%s:%s", PVEHost, PVEPort)
+	}
+	PVEURL = strings.TrimSuffix(PVEURL, "/")
+	if !strings.HasSuffix(PVEURL, "/api2/json") {
+		PVEURL += "/api2/json"
+	}
+	log.Printf("🔐 Proxmox TLS: %s", PVETLS.Mode())
+}
+
+// parseDuration parses a Go duration string, falling back to def when empty or invalid
+func parseDuration(s string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+// parseInt parses a positive integer, falling back to def when empty or invalid
+func parseInt(s string, def int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n > 0 {
+		return n
+	}
+	return def
 }
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Connection is one active flow reported by the Clash API /connections
+type Connection struct {
+	ID       string   `json:"id"`
+	Upload   uint64   `json:"upload"`
+	Download uint64   `json:"download"`
+	Start    string   `json:"start"`
+	Chains   []string `json:"chains"` // Outbound chain, last hop first
+	Rule     string   `json:"rule"`
+	Payload  string   `json:"rulePayload"`
+	Metadata struct {
+		Network         string `json:"network"`
+		SourceIP        string `json:"sourceIP"`
+		DestinationIP   string `json:"destinationIP"`
+		DestinationPort string `json:"destinationPort"`
+		Host            string `json:"host"`
+	} `json:"metadata"`
+}
+
+// Target returns the host (or destination IP) and port the flow goes to
+func (c Connection) Target() string {
+	host := c.Metadata.Host
+	if host == "" {
+		host = c.Metadata.DestinationIP
+	}
+	if c.Metadata.DestinationPort == "" {
+		return host
+	}
+	return net.JoinHostPort(host, c.Metadata.DestinationPort)
+}
+
+// Chain renders the outbound chain from the first hop to the exit ("ExitNode → WG-SG")
+func (c Connection) Chain() string {
+	hops := make([]string, len(c.Chains))
+	for i, hop := range c.Chains {
+		hops[len(c.Chains)-1-i] = hop
+	}
+	return strings.Join(hops, " → ")
+}
+
+// ConnectionsSnapshot is the /connections response
+type ConnectionsSnapshot struct {
+	DownloadTotal uint64       `json:"downloadTotal"`
+	UploadTotal   uint64       `json:"uploadTotal"`
+	Connections   []Connection `json:"connections"`
+}
+
+// GetConnections fetches the active connections, largest (upload+download) first
+func GetConnections(ctx context.Context) (ConnectionsSnapshot, error) {
+	client, err := NewHTTPClient(5*time.Second, SingboxTLS)
+	if err != nil {
+		return ConnectionsSnapshot{}, err
+	}
+
+	resp, err := singboxDo(ctx, client, "GET", "/connections", nil)
+	if err != nil {
+		return ConnectionsSnapshot{}, err
+	}
+	defer resp.Body.Close()
+
+	var snap ConnectionsSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+		return ConnectionsSnapshot{}, err
+	}
+	sort.Slice(snap.Connections, func(i, j int) bool {
+		a, b := snap.Connections[i], snap.Connections[j]
+		return a.Upload+a.Download > b.Upload+b.Download
+	})
+	return snap, nil
+}
+
+// FilterConnections keeps connections whose host, addresses, rule or chain contain query (case-insensitive)
+func FilterConnections(conns []Connection, query string) []Connection {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return conns
+	}
+	var out []Connection
+	for _, c := range conns {
+		fields := []string{c.Target(), c.Metadata.SourceIP, c.Metadata.DestinationIP, c.Rule, c.Chain()}
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), query) {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// CloseAllConnections drops every active connection so new flows use the current outbounds
+func CloseAllConnections(ctx context.Context) error {
+	client, err := NewHTTPClient(5*time.Second, SingboxTLS)
+	if err != nil {
+		return err
+	}
+	resp, err := singboxDo(ctx, client, "DELETE", "/connections", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// KillConnections closes the active connections matching filter (see FilterConnections)
+// and returns how many were closed
+func KillConnections(ctx context.Context, filter string) (int, error) {
+	if strings.TrimSpace(filter) == "" {
+		return 0, fmt.Errorf("a filter is required; use CloseAllConnections to close everything")
+	}
+
+	snap, err := GetConnections(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := NewHTTPClient(5*time.Second, SingboxTLS)
+	if err != nil {
+		return 0, err
+	}
+	closed := 0
+	for _, c := range FilterConnections(snap.Connections, filter) {
+		resp, err := singboxDo(ctx, client, "DELETE", "/connections/"+url.PathEscape(c.ID), nil)
+		var statusErr *singboxStatusError
+		if errors.As(err, &statusErr) && statusErr.Status == http.StatusNotFound {
+			// Closed on its own since the list was read
+			continue
+		}
+		if err != nil {
+			return closed, err
+		}
+		resp.Body.Close()
+		closed++
+	}
+	return closed, nil
+}
+
+// getTraffic reads one sample from the streaming /traffic endpoint (bytes per second)
+func getTraffic(ctx context.Context) (up, down uint64, err error) {
+	client, err := NewHTTPClient(3*time.Second, SingboxTLS)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	resp, err := singboxDo(ctx, client, "GET", "/traffic", nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	// The stream never ends; closing the body after the first sample drops the connection
+	defer resp.Body.Close()
+
+	var sample struct {
+		Up   uint64 `json:"up"`
+		Down uint64 `json:"down"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&sample); err != nil {
+		return 0, 0, err
+	}
+	return sample.Up, sample.Down, nil
+}
+
+// FormatConnectionsTable renders the first limit connections as a fixed-width table for code blocks
+func FormatConnectionsTable(conns []Connection, limit int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-28s %-14s %-22s %s\n", "HOST", "RULE", "CHAIN", "↑/↓"))
+	for i, c := range conns {
+		if i >= limit {
+			sb.WriteString(fmt.Sprintf("… +%d kết nối\n", len(conns)-limit))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%-28s %-14s %-22s %s/%s\n",
+			clip(c.Target(), 28), clip(c.Rule, 14), clip(c.Chain(), 22),
+			FormatBytes(c.Upload), FormatBytes(c.Download)))
+	}
+	return sb.String()
+}
+
+// FormatTrafficLine summarises proxy throughput and totals in one line
+func FormatTrafficLine(info SingboxInfo) string {
+	return fmt.Sprintf("↑ %s/s ↓ %s/s | Tổng ↑ %s ↓ %s | %d kết nối",
+		FormatBytes(info.UpRate), FormatBytes(info.DownRate),
+		FormatBytes(info.UploadTotal), FormatBytes(info.DownloadTotal),
+		info.Connections)
+}
+
+// clip shortens s to at most n runes, marking the cut with "…"
+func clip(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// dashboardTimeout is how long GetDashboardData waits for all sources
+const dashboardTimeout = 5 * time.Second
+
 // GetDashboardData aggregates all monitoring data using goroutines and channels
 // This is the main optimization: all data sources are fetched concurrently
 func GetDashboardData(ctx context.Context) (*DashboardData, error) {
@@ -14,15 +17,19 @@ func GetDashboardData(ctx context.Context) (*DashboardData, error) {
 	proxmoxChan := make(chan ProxmoxInfo, 1)
 	singboxChan := make(chan SingboxInfo, 1)
 	pppoeChan := make(chan PPPoESpeed, 1)
+	ifaceChan := make(chan []InterfaceStats, 1)
+	deviceChan := make(chan []DeviceInfo, 1)
 
 	// Launch goroutines to fetch data concurrently
 	go GetMikroTikInfo(ctx, mikrotikChan)
 	go GetProxmoxInfo(ctx, proxmoxChan)
 	go GetSingboxInfo(ctx, singboxChan)
 	go GetPPPoESpeed(ctx, pppoeChan)
+	go GetInterfaceStats(ctx, ifaceChan)
+	go GetSNMPDevices(ctx, deviceChan)
 
 	// Create timeout context (max 5 seconds for all operations)
-	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	timeoutCtx, cancel := context.WithTimeout(ctx, dashboardTimeout)
 	defer cancel()
 
 	// Collect results from channels
@@ -30,10 +37,12 @@ func GetDashboardData(ctx context.Context) (*DashboardData, error) {
 	var proxmox ProxmoxInfo
 	var singbox SingboxInfo
 	var pppoe PPPoESpeed
+	var ifaces []InterfaceStats
+	var devices []DeviceInfo
 
 	// Wait for all results or timeout
 	resultsReceived := 0
-	for resultsReceived < 4 {
+	for resultsReceived < 6 {
 		select {
 		case m, ok := <-mikrotikChan:
 			if ok {
@@ -59,6 +68,18 @@ func GetDashboardData(ctx context.Context) (*DashboardData, error) {
 				resultsReceived++
 				pppoeChan = nil
 			}
+		case f, ok := <-ifaceChan:
+			if ok {
+				ifaces = f
+				resultsReceived++
+				ifaceChan = nil
+			}
+		case v, ok := <-deviceChan:
+			if ok {
+				devices = v
+				resultsReceived++
+				deviceChan = nil
+			}
 		case <-timeoutCtx.Done():
 			// Timeout: return partial data
 			return nil, fmt.Errorf("timeout fetching dashboard data")
@@ -72,10 +93,14 @@ func GetDashboardData(ctx context.Context) (*DashboardData, error) {
 		proxmox, mikrotik, pppoe, singbox)
 
 	return &DashboardData{
-		MikroTik:  mikrotik,
-		Proxmox:   proxmox,
-		Singbox:   singbox,
-		PPPoE:     pppoe,
-		Timestamp: timestamp,
+		MikroTik:   mikrotik,
+		Proxmox:    proxmox,
+		Singbox:    singbox,
+		PPPoE:      pppoe,
+		Interfaces: ifaces,
+		Devices:    devices,
+		Timestamp:  timestamp,
+
+		StoppedOnly: PVEOnlyStop,
 	}, nil
 }
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// delayHistory keeps the latest DELAY_HISTORY_SIZE delay results per node
+// (positive ms, DelayTimeout or DelayError)
+var delayHistory = struct {
+	sync.Mutex
+	samples map[string][]int
+}{samples: make(map[string][]int)}
+
+// DelayStats summarises a node's delay history
+type DelayStats struct {
+	Samples  int
+	Last     int // Same encoding as NodeDelays
+	Median   int
+	P95      int
+	Timeouts int
+	Errors   int
+}
+
+// Loss is the share of completed tests that timed out, in percent. Errors mean the test itself
+// could not run (e.g. the bot's API request failed), so they count neither way.
+func (s DelayStats) Loss() float64 {
+	tested := s.Samples - s.Errors
+	if tested <= 0 {
+		return 0
+	}
+	return float64(s.Timeouts) * 100 / float64(tested)
+}
+
+// RecordDelay appends one test result to the node's history
+func RecordDelay(node string, delay int) {
+	if delay == 0 {
+		return
+	}
+	delayHistory.Lock()
+	defer delayHistory.Unlock()
+
+	h := append(delayHistory.samples[node], delay)
+	if len(h) > DelayHistorySize {
+		h = h[len(h)-DelayHistorySize:]
+	}
+	delayHistory.samples[node] = h
+}
+
+// GetDelayStats computes median/p95 over successful tests and counts timeouts and errors
+func GetDelayStats(node string) DelayStats {
+	delayHistory.Lock()
+	h := append([]int(nil), delayHistory.samples[node]...)
+	delayHistory.Unlock()
+
+	stats := DelayStats{Samples: len(h)}
+	if len(h) == 0 {
+		return stats
+	}
+	stats.Last = h[len(h)-1]
+
+	var ok []int
+	for _, d := range h {
+		switch {
+		case d > 0:
+			ok = append(ok, d)
+		case d == DelayTimeout:
+			stats.Timeouts++
+		default:
+			stats.Errors++
+		}
+	}
+	if len(ok) > 0 {
+		sort.Ints(ok)
+		stats.Median = percentile(ok, 50)
+		stats.P95 = percentile(ok, 95)
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int, p int) int {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// FormatNodesTable renders delay statistics for every node of the groups as code-block tables
+func FormatNodesTable(groups []ProxyGroup) string {
+	var sb strings.Builder
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprintf("[%s] %s\n", g.Name, g.Now))
+		sb.WriteString(fmt.Sprintf("  %-20s %-8s %-7s %-7s %-6s %s\n", "NODE", "LAST", "MED", "P95", "LOSS", "N"))
+		for _, node := range g.All {
+			st := GetDelayStats(node)
+			mark := " "
+			if node == g.Now {
+				mark = "*"
+			}
+			med, p95 := "-", "-"
+			if st.Median > 0 {
+				med, p95 = fmt.Sprintf("%dms", st.Median), fmt.Sprintf("%dms", st.P95)
+			}
+			sb.WriteString(fmt.Sprintf("%s %-20s %-8s %-7s %-7s %-6s %d\n",
+				mark, clip(node, 20), FormatDelay(st.Last), med, p95, fmt.Sprintf("%.0f%%", st.Loss()), st.Samples))
+		}
+	}
+	return sb.String()
+}
+
+// StartDelayHistory tests every exposed group each DELAY_HISTORY_INTERVAL so /nodes has data
+// even when nobody opens the dashboard
+func StartDelayHistory() {
+	if DelayHistoryInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(DelayHistoryInterval)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), SingboxDelayTimeout+10*time.Second)
+			if err := TestGroupDelays(ctx); err != nil {
+				log.Printf("⚠️  Delay history test failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// TestGroupDelays runs the delay test of every exposed group and records one sample per node
+func TestGroupDelays(ctx context.Context) error {
+	client, err := NewHTTPClient(SingboxDelayTimeout+5*time.Second, SingboxTLS)
+	if err != nil {
+		return err
+	}
+	groups, err := listProxyGroups(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu     sync.Mutex
+		delays = make(map[string]int)
+		wg     sync.WaitGroup
+	)
+	for _, g := range groups {
+		wg.Add(1)
+		go func(g ProxyGroup) {
+			defer wg.Done()
+			result := groupDelays(ctx, client, g.Name, g.All, SingboxDelayTimeout)
+			mu.Lock()
+			for _, node := range g.All {
+				d := result[node]
+				// A node in several groups keeps its best result of the round
+				if prev, ok := delays[node]; !ok || d > 0 && (prev <= 0 || d < prev) {
+					delays[node] = d
+				}
+			}
+			mu.Unlock()
+		}(g)
+	}
+	wg.Wait()
+
+	// This is the only place history is recorded, so samples are evenly spaced
+	// and do not depend on how often the dashboard is opened
+	for node, d := range delays {
+		RecordDelay(node, d)
+	}
+	return nil
+}
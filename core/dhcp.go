@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DHCPLease is one entry of the router's DHCP server lease table
+type DHCPLease struct {
+	HostName string
+	MAC      string
+	Address  string
+	Status   string // "bound", "waiting", "offered"...
+	LastSeen string // RouterOS duration, e.g. "2m13s"
+	Comment  string
+	Dynamic  bool
+}
+
+// GetDHCPLeases reads /ip/dhcp-server/lease over the RouterOS API
+func GetDHCPLeases(ctx context.Context) ([]DHCPLease, error) {
+	api, err := DialMikroTikAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer api.Close()
+
+	rows, err := api.Run("/ip/dhcp-server/lease/print",
+		"=.proplist=host-name,mac-address,active-address,address,status,last-seen,comment,dynamic")
+	if err != nil {
+		return nil, err
+	}
+
+	leases := make([]DHCPLease, 0, len(rows))
+	for _, row := range rows {
+		addr := row["active-address"]
+		if addr == "" {
+			addr = row["address"]
+		}
+		leases = append(leases, DHCPLease{
+			HostName: row["host-name"],
+			MAC:      strings.ToUpper(row["mac-address"]),
+			Address:  addr,
+			Status:   row["status"],
+			LastSeen: row["last-seen"],
+			Comment:  row["comment"],
+			Dynamic:  row["dynamic"] == "true",
+		})
+	}
+
+	sort.Slice(leases, func(i, j int) bool { return leaseAddrLess(leases[i].Address, leases[j].Address) })
+	return leases, nil
+}
+
+// leaseAddrLess orders addresses numerically (.9 before .10); unparsable ones go last
+func leaseAddrLess(a, b string) bool {
+	ia, errA := netip.ParseAddr(a)
+	ib, errB := netip.ParseAddr(b)
+	switch {
+	case errA != nil && errB != nil:
+		return a < b
+	case errA != nil || errB != nil:
+		return errB != nil
+	}
+	return ia.Less(ib)
+}
+
+// SearchLeases filters leases by case-insensitive substring of host name, comment, MAC or IP
+func SearchLeases(leases []DHCPLease, query string) []DHCPLease {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return leases
+	}
+	// Accept MACs typed with "-" as well as ":"
+	macQuery := strings.ReplaceAll(query, "-", ":")
+
+	var out []DHCPLease
+	for _, l := range leases {
+		if strings.Contains(strings.ToLower(l.HostName), query) ||
+			strings.Contains(strings.ToLower(l.Comment), query) ||
+			strings.Contains(strings.ToLower(l.MAC), macQuery) ||
+			strings.Contains(l.Address, query) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Name returns the best human label for a lease
+func (l DHCPLease) Name() string {
+	switch {
+	case l.Comment != "":
+		return l.Comment
+	case l.HostName != "":
+		return l.HostName
+	}
+	return "?"
+}
+
+// FormatLeaseTable renders leases as a fixed-width table for code blocks
+func FormatLeaseTable(leases []DHCPLease) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-18s %-15s %-17s %-7s %s\n", "NAME", "IP", "MAC", "STATUS", "SEEN"))
+	for _, l := range leases {
+		name := clip(l.Name(), 18)
+		sb.WriteString(fmt.Sprintf("%-18s %-15s %-17s %-7s %s\n", name, l.Address, l.MAC, l.Status, l.LastSeen))
+	}
+	return sb.String()
+}
+
+// isKnownLease treats static leases, commented leases and KNOWN_MACS as known devices
+func isKnownLease(l DHCPLease) bool {
+	return !l.Dynamic || l.Comment != "" || contains(KnownMACs, l.MAC)
+}
+
+// StartClientWatcher notifies both chats when a bound lease appears for an unknown MAC.
+// The first poll only records the current clients so a restart doesn't flood the chats.
+func StartClientWatcher() {
+	if !NotifyUnknownClients {
+		return
+	}
+
+	go func() {
+		seen := make(map[string]bool)
+		first := true
+
+		ticker := time.NewTicker(ClientWatchInterval)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			leases, err := GetDHCPLeases(ctx)
+			cancel()
+			if err != nil {
+				log.Printf("⚠️  DHCP lease poll failed: %v", err)
+				continue
+			}
+
+			for _, l := range leases {
+				if l.Status != "bound" || seen[l.MAC] {
+					continue
+				}
+				seen[l.MAC] = true
+				if !first && !isKnownLease(l) {
+					Notify(fmt.Sprintf("🆕 Thiết bị lạ vào mạng: %s | %s | %s", l.Name(), l.Address, l.MAC))
+				}
+			}
+			first = false
+		}
+	}()
+}
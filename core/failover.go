@@ -0,0 +1,249 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failover is the state of the VPN exit node watchdog
+var failover = struct {
+	sync.Mutex
+	pinned     bool
+	failures   int // Consecutive failed or slow checks of the current node
+	lastDelay  int
+	lastError  string
+	lastSwitch time.Time
+	lastReason string
+}{}
+
+// FailoverStatus is a snapshot of the watchdog for /failover
+type FailoverStatus struct {
+	Enabled    bool
+	Group      string
+	Policy     string
+	Pinned     bool
+	Failures   int
+	LastDelay  int
+	LastError  string
+	LastSwitch time.Time
+	LastReason string
+}
+
+// GetFailoverStatus returns the current watchdog state
+func GetFailoverStatus() FailoverStatus {
+	failover.Lock()
+	defer failover.Unlock()
+	return FailoverStatus{
+		Enabled:    FailoverEnabled,
+		Group:      FailoverGroup,
+		Policy:     FailoverPolicy,
+		Pinned:     failover.pinned,
+		Failures:   failover.failures,
+		LastDelay:  failover.lastDelay,
+		LastError:  failover.lastError,
+		LastSwitch: failover.lastSwitch,
+		LastReason: failover.lastReason,
+	}
+}
+
+// PinFailover stops (pinned=true) or resumes automatic switching
+func PinFailover(pinned bool) {
+	failover.Lock()
+	failover.pinned = pinned
+	failover.failures = 0
+	failover.Unlock()
+}
+
+// FormatFailoverStatus renders the watchdog state for chat messages
+func FormatFailoverStatus(st FailoverStatus) string {
+	if !st.Enabled {
+		return "🛡 Failover: tắt (FAILOVER_ENABLED=false)"
+	}
+
+	var sb strings.Builder
+	state := "đang chạy"
+	if st.Pinned {
+		state = "📌 đã ghim (không tự chuyển)"
+	}
+	sb.WriteString(fmt.Sprintf("🛡 Failover %s: %s\n", st.Group, state))
+	sb.WriteString(fmt.Sprintf("Chính sách: %s | Ngưỡng: %d lần lỗi", st.Policy, FailoverFailures))
+	if FailoverMaxDelay > 0 {
+		sb.WriteString(fmt.Sprintf(", > %dms", FailoverMaxDelay.Milliseconds()))
+	}
+	sb.WriteString("\n")
+	if st.LastError != "" {
+		sb.WriteString(fmt.Sprintf("Lần kiểm tra cuối: ❌ %s (%d/%d)\n", st.LastError, st.Failures, FailoverFailures))
+	} else if st.LastDelay > 0 {
+		sb.WriteString(fmt.Sprintf("Lần kiểm tra cuối: %dms (%d/%d)\n", st.LastDelay, st.Failures, FailoverFailures))
+	}
+	if !st.LastSwitch.IsZero() {
+		sb.WriteString(fmt.Sprintf("Lần chuyển cuối: %s — %s\n",
+			st.LastSwitch.In(vietnamTZ).Format("15:04:05 02/01"), st.LastReason))
+	}
+	return sb.String()
+}
+
+// StartFailoverWatchdog tests the current exit node every FAILOVER_INTERVAL and switches away
+// after FAILOVER_FAILURES consecutive failed (or too slow) checks
+func StartFailoverWatchdog() {
+	if !FailoverEnabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(FailoverInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := checkFailover(ctx); err != nil {
+				log.Printf("⚠️  Failover check failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// checkFailover runs one watchdog round
+func checkFailover(ctx context.Context) error {
+	client, err := NewHTTPClient(max(5*time.Second, SingboxDelayTimeout+time.Second), SingboxTLS)
+	if err != nil {
+		return err
+	}
+
+	group, err := getProxyGroup(ctx, client, FailoverGroup)
+	if err != nil {
+		return err
+	}
+	if !group.Switchable() {
+		return fmt.Errorf("%s is a %s group and cannot be switched", group.Name, group.Type)
+	}
+
+	delay, err := NodeDelay(ctx, client, group.Now)
+	reason := ""
+	switch {
+	case err != nil:
+		reason = fmt.Sprintf("%s lỗi %d lần liên tiếp (%v)", group.Now, FailoverFailures, err)
+	case FailoverMaxDelay > 0 && delay > int(FailoverMaxDelay.Milliseconds()):
+		reason = fmt.Sprintf("%s chậm %d lần liên tiếp (%dms > %dms)", group.Now, FailoverFailures, delay, FailoverMaxDelay.Milliseconds())
+	}
+
+	failover.Lock()
+	failover.lastDelay = delay
+	failover.lastError = ""
+	if err != nil {
+		failover.lastError = err.Error()
+	}
+	if reason == "" {
+		failover.failures = 0
+	} else {
+		failover.failures++
+	}
+	trigger := reason != "" && failover.failures >= FailoverFailures && !failover.pinned
+	// The hold only damps switching away from a slow node; a node that fails outright is always left
+	held := err == nil && FailoverPolicy == "sticky" && time.Since(failover.lastSwitch) < FailoverHold
+	failover.Unlock()
+
+	if !trigger {
+		return nil
+	}
+	if held {
+		log.Printf("🛡 Failover held: last switch less than %s ago", FailoverHold)
+		return nil
+	}
+
+	next, nextDelay, ok := pickFailoverNode(ctx, client, group, delay)
+	if ok && next == group.Now {
+		// Sticky policy: nothing clearly better, keep the slow node
+		failover.Lock()
+		failover.failures = 0
+		failover.Unlock()
+		return nil
+	}
+	if !ok {
+		Notify(fmt.Sprintf("🛡 Failover %s: %s nhưng không có node nào khỏe để chuyển", group.Name, reason))
+		// Reset so the "no healthy node" message repeats at most every FAILOVER_FAILURES checks
+		failover.Lock()
+		failover.failures = 0
+		failover.Unlock()
+		return nil
+	}
+
+	// Not a manual switch: the node schedule must neither hold nor revert it
+	if err := switchNode(group.Name, next); err != nil {
+		return err
+	}
+	if err := CloseAfterSwitch(ctx); err != nil {
+		log.Printf("⚠️  Failover %s: %v", group.Name, err)
+	}
+
+	failover.Lock()
+	failover.failures = 0
+	failover.lastSwitch = time.Now()
+	failover.lastReason = reason
+	failover.Unlock()
+
+	log.Printf("🛡 Failover %s: %s -> %s (%s)", group.Name, group.Now, next, reason)
+	Notify(fmt.Sprintf("🔀 Failover %s: %s → %s (%dms)\nLý do: %s", group.Name, group.Now, next, nextDelay, reason))
+	return nil
+}
+
+// pickFailoverNode tests every other member of the group and chooses one according to FAILOVER_POLICY
+func pickFailoverNode(ctx context.Context, client *http.Client, group ProxyGroup, currentDelay int) (string, int, bool) {
+	type candidate struct {
+		node  string
+		delay int
+	}
+
+	var (
+		mu      sync.Mutex
+		healthy []candidate
+		wg      sync.WaitGroup
+	)
+	for _, node := range group.All {
+		if node == group.Now {
+			continue
+		}
+		wg.Add(1)
+		go func(node string) {
+			defer wg.Done()
+			delay, err := NodeDelay(ctx, client, node)
+			if err != nil || (FailoverMaxDelay > 0 && delay > int(FailoverMaxDelay.Milliseconds())) {
+				return
+			}
+			mu.Lock()
+			healthy = append(healthy, candidate{node, delay})
+			mu.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	if len(healthy) == 0 {
+		return "", 0, false
+	}
+	sort.Slice(healthy, func(i, j int) bool { return healthy[i].delay < healthy[j].delay })
+
+	switch FailoverPolicy {
+	case "preferred":
+		// First healthy node in FAILOVER_PREFERRED order, otherwise the fastest
+		for _, want := range FailoverPreferred {
+			for _, c := range healthy {
+				if strings.EqualFold(c.node, want) {
+					return c.node, c.delay, true
+				}
+			}
+		}
+	case "sticky":
+		// A slow (but reachable) node is only left for one that is clearly faster
+		if currentDelay > 0 && healthy[0].delay+int(FailoverMargin.Milliseconds()) > currentDelay {
+			return group.Now, currentDelay, true
+		}
+	}
+	return healthy[0].node, healthy[0].delay, true
+}
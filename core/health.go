@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// MIKROTIK-MIB health OIDs
+const (
+	// RouterOS 7 gauge table: .2 name, .3 value, .4 unit
+	oidMtxrGaugeTable = "1.3.6.1.4.1.14988.1.1.3.100.1"
+	oidMtxrGaugeName  = oidMtxrGaugeTable + ".2"
+	oidMtxrGaugeValue = oidMtxrGaugeTable + ".3"
+	oidMtxrGaugeUnit  = oidMtxrGaugeTable + ".4"
+)
+
+// legacyHealthOIDs are the RouterOS 6 mtxrHealth scalars with their divisor and unit
+var legacyHealthOIDs = []struct {
+	oid     string
+	name    string
+	divisor float64
+	unit    string
+}{
+	{"1.3.6.1.4.1.14988.1.1.3.10.0", "temperature", 10, "C"},
+	{"1.3.6.1.4.1.14988.1.1.3.11.0", "cpu-temperature", 10, "C"},
+	{"1.3.6.1.4.1.14988.1.1.3.7.0", "board-temperature", 10, "C"},
+	{"1.3.6.1.4.1.14988.1.1.3.8.0", "voltage", 10, "V"},
+	{"1.3.6.1.4.1.14988.1.1.3.17.0", "fan1-speed", 1, "RPM"},
+	{"1.3.6.1.4.1.14988.1.1.3.18.0", "fan2-speed", 1, "RPM"},
+}
+
+// gaugeUnits maps mtxrGaugeUnit values to a display unit and divisor
+var gaugeUnits = map[int64]struct {
+	unit    string
+	divisor float64
+}{
+	1: {"C", 1},   // celsius
+	2: {"RPM", 1}, // rpm
+	3: {"V", 10},  // dV
+	4: {"A", 10},  // dA
+	5: {"W", 10},  // dW
+	6: {"", 1},    // status
+}
+
+func init() {
+	RegisterMetricSource("mikrotik", mikrotikHealthMetrics)
+}
+
+// getSNMPHealth reads health sensors via the RouterOS 7 gauge table, falling back to the v6 scalars
+func getSNMPHealth(snmp *gosnmp.GoSNMP) ([]HealthSensor, error) {
+	pdus, err := snmp.BulkWalkAll(oidMtxrGaugeTable)
+	if err == nil && len(pdus) > 0 {
+		return parseGaugeTable(pdus), nil
+	}
+
+	oids := make([]string, len(legacyHealthOIDs))
+	for i, h := range legacyHealthOIDs {
+		oids[i] = h.oid
+	}
+	result, err := snmp.Get(oids)
+	if err != nil {
+		return nil, err
+	}
+
+	var sensors []HealthSensor
+	for i, v := range result.Variables {
+		if i >= len(legacyHealthOIDs) || snmpMissing(v) {
+			continue
+		}
+		h := legacyHealthOIDs[i]
+		n := float64(gosnmp.ToBigInt(v.Value).Int64()) / h.divisor
+		// Boards without a sensor report 0
+		if n == 0 {
+			continue
+		}
+		sensors = append(sensors, newHealthSensor(h.name, n, h.unit))
+	}
+	return sensors, nil
+}
+
+// parseGaugeTable turns mtxrGaugeTable rows into sensors keyed by the row index
+func parseGaugeTable(pdus []gosnmp.SnmpPDU) []HealthSensor {
+	type row struct {
+		name  string
+		value int64
+		unit  int64
+	}
+	rows := make(map[string]*row)
+	var order []string
+
+	for _, pdu := range pdus {
+		name := strings.TrimPrefix(pdu.Name, ".")
+		var column string
+		switch {
+		case strings.HasPrefix(name, oidMtxrGaugeName+"."):
+			column = oidMtxrGaugeName
+		case strings.HasPrefix(name, oidMtxrGaugeValue+"."):
+			column = oidMtxrGaugeValue
+		case strings.HasPrefix(name, oidMtxrGaugeUnit+"."):
+			column = oidMtxrGaugeUnit
+		default:
+			continue
+		}
+		index := strings.TrimPrefix(name, column+".")
+		r := rows[index]
+		if r == nil {
+			r = &row{}
+			rows[index] = r
+			order = append(order, index)
+		}
+		switch column {
+		case oidMtxrGaugeName:
+			r.name = snmpString(pdu)
+		case oidMtxrGaugeValue:
+			r.value = gosnmp.ToBigInt(pdu.Value).Int64()
+		case oidMtxrGaugeUnit:
+			r.unit = gosnmp.ToBigInt(pdu.Value).Int64()
+		}
+	}
+
+	sensors := make([]HealthSensor, 0, len(order))
+	for _, index := range order {
+		r := rows[index]
+		u, ok := gaugeUnits[r.unit]
+		if !ok {
+			u.divisor = 1
+		}
+		sensors = append(sensors, newHealthSensor(r.name, float64(r.value)/u.divisor, u.unit))
+	}
+	return sensors
+}
+
+func newHealthSensor(name string, n float64, unit string) HealthSensor {
+	return HealthSensor{
+		Name:   name,
+		Value:  strconv.FormatFloat(n, 'f', -1, 64),
+		Unit:   unit,
+		Number: n,
+	}
+}
+
+// mikrotikHealthMetrics exposes health sensors to the alert engine as mikrotik.<sensor>
+func mikrotikHealthMetrics(ctx context.Context) (map[string]float64, error) {
+	var sensors []HealthSensor
+	if MikroTikCollector == "api" {
+		info, err := getRouterOSInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sensors = info.Health
+	} else {
+		snmp, err := NewSNMPClient(MikroTikSNMP)
+		if err != nil {
+			return nil, err
+		}
+		defer snmp.Conn.Close()
+		if sensors, err = getSNMPHealth(snmp); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := make(map[string]float64, len(sensors))
+	for _, s := range sensors {
+		metrics[s.Name] = s.Number
+	}
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no health sensors reported")
+	}
+	return metrics, nil
+}
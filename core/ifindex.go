@@ -0,0 +1,199 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// IF-MIB columns used for interface discovery
+const (
+	oidIfDescr      = "1.3.6.1.2.1.2.2.1.2"
+	oidIfOperStatus = "1.3.6.1.2.1.2.2.1.8"
+	oidIfName       = "1.3.6.1.2.1.31.1.1.1.1"
+)
+
+// SNMPInterface is one row of the router's interface table
+type SNMPInterface struct {
+	Index int
+	Name  string // ifName
+	Descr string // ifDescr
+	Up    bool   // ifOperStatus == up(1)
+}
+
+// ifCache maps lowercased interface names (ifName and ifDescr) to ifIndex, and back.
+// Names that could not be resolved are remembered until retryAt so a typo or a down PPPoE
+// link does not trigger a full interface walk on every sampler tick.
+var ifCache = struct {
+	sync.Mutex
+	byName  map[string]int
+	byIndex map[int]string
+	retryAt map[string]time.Time
+}{byName: make(map[string]int), byIndex: make(map[int]string), retryAt: make(map[string]time.Time)}
+
+// unresolvedRetry is how long a name that failed to resolve is not looked up again
+const unresolvedRetry = time.Minute
+
+// DiscoverInterfaces walks ifDescr/ifName/ifOperStatus on the MikroTik and refreshes the name cache
+func DiscoverInterfaces(ctx context.Context) ([]SNMPInterface, error) {
+	snmp, err := NewSNMPClient(MikroTikSNMP)
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+	snmp.Context = ctx
+
+	ifaces := make(map[int]*SNMPInterface)
+	row := func(index int) *SNMPInterface {
+		if ifaces[index] == nil {
+			ifaces[index] = &SNMPInterface{Index: index}
+		}
+		return ifaces[index]
+	}
+
+	for _, column := range []string{oidIfDescr, oidIfName, oidIfOperStatus} {
+		pdus, err := snmp.BulkWalkAll(column)
+		if err != nil {
+			return nil, err
+		}
+		for _, pdu := range pdus {
+			index, err := strconv.Atoi(pdu.Name[strings.LastIndex(pdu.Name, ".")+1:])
+			if err != nil {
+				continue
+			}
+			switch column {
+			case oidIfDescr:
+				row(index).Descr = snmpString(pdu)
+			case oidIfName:
+				row(index).Name = snmpString(pdu)
+			case oidIfOperStatus:
+				row(index).Up = gosnmp.ToBigInt(pdu.Value).Int64() == 1
+			}
+		}
+	}
+
+	result := make([]SNMPInterface, 0, len(ifaces))
+	byName := make(map[string]int, 2*len(ifaces))
+	byIndex := make(map[int]string, len(ifaces))
+	for _, iface := range ifaces {
+		result = append(result, *iface)
+		byIndex[iface.Index] = iface.Name
+		if iface.Name == "" {
+			byIndex[iface.Index] = iface.Descr
+		}
+		if iface.Descr != "" {
+			byName[strings.ToLower(iface.Descr)] = iface.Index
+		}
+		if iface.Name != "" {
+			byName[strings.ToLower(iface.Name)] = iface.Index
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+
+	ifCache.Lock()
+	ifCache.byName = byName
+	ifCache.byIndex = byIndex
+	ifCache.Unlock()
+
+	return result, nil
+}
+
+// ResolveInterface returns the ifIndex for an interface name, walking the router if it isn't cached.
+// A purely numeric name is treated as an index, which keeps the old PPPOE_INDEX style working.
+func ResolveInterface(ctx context.Context, name string) (int, error) {
+	if index, err := strconv.Atoi(name); err == nil {
+		return index, nil
+	}
+
+	key := strings.ToLower(name)
+	ifCache.Lock()
+	index, ok := ifCache.byName[key]
+	retryAt := ifCache.retryAt[key]
+	ifCache.Unlock()
+	if ok {
+		return index, nil
+	}
+	if time.Now().Before(retryAt) {
+		return 0, fmt.Errorf("interface %q not found (retry at %s)", name, retryAt.Format("15:04:05"))
+	}
+
+	_, err := DiscoverInterfaces(ctx)
+
+	ifCache.Lock()
+	defer ifCache.Unlock()
+	if err == nil {
+		index, ok = ifCache.byName[key]
+		if !ok {
+			err = fmt.Errorf("interface %q not found", name)
+		}
+	}
+	if err != nil {
+		ifCache.retryAt[key] = time.Now().Add(unresolvedRetry)
+		return 0, err
+	}
+	delete(ifCache.retryAt, key)
+	return index, nil
+}
+
+// InterfaceName returns the cached name for an ifIndex, or "" if unknown
+func InterfaceName(index int) string {
+	ifCache.Lock()
+	defer ifCache.Unlock()
+	return ifCache.byIndex[index]
+}
+
+// InvalidateInterface drops a cached name so the next ResolveInterface walks the router again.
+// Called when a lookup by index fails, e.g. after the PPPoE client was recreated.
+func InvalidateInterface(name string) {
+	ifCache.Lock()
+	delete(ifCache.byName, strings.ToLower(name))
+	delete(ifCache.retryAt, strings.ToLower(name))
+	ifCache.Unlock()
+}
+
+// snmpString converts an OctetString PDU into a Go string
+func snmpString(pdu gosnmp.SnmpPDU) string {
+	if b, ok := pdu.Value.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(pdu.Value)
+}
+
+// snmpMissing reports whether a PDU carries no value (e.g. the index no longer exists)
+func snmpMissing(pdu gosnmp.SnmpPDU) bool {
+	return pdu.Value == nil || pdu.Type == gosnmp.NoSuchInstance || pdu.Type == gosnmp.NoSuchObject
+}
+
+// FormatInterfaceTable renders discovered interfaces as a fixed-width table for code blocks
+func FormatInterfaceTable(ifaces []SNMPInterface) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-5s %-2s %-20s %s\n", "IDX", "UP", "NAME", "DESCR"))
+	for _, iface := range ifaces {
+		up := "✗"
+		if iface.Up {
+			up = "✓"
+		}
+		sb.WriteString(fmt.Sprintf("%-5d %-2s %-20s %s\n", iface.Index, up, iface.Name, iface.Descr))
+	}
+	return sb.String()
+}
+
+// WarmInterfaceCache resolves interface names once at startup so the first dashboard is fast
+func WarmInterfaceCache() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ifaces, err := DiscoverInterfaces(ctx)
+	if err != nil {
+		log.Printf("⚠️  SNMP interface discovery failed: %v", err)
+		return
+	}
+	log.Printf("📡 SNMP: discovered %d interfaces", len(ifaces))
+}
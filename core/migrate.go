@@ -0,0 +1,313 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MigrationTimeout bounds the migration of one guest, including each guest of a drain
+const MigrationTimeout = 30 * time.Minute
+
+// MigrationResult describes the outcome of one guest migration
+type MigrationResult struct {
+	VM       VMInfo
+	Target   string
+	Online   bool
+	Duration time.Duration
+	TimedOut bool // The PVE task was still running at MigrationTimeout
+	Error    string
+}
+
+// FindVM looks up a guest in the cluster by VMID or (case-insensitive) name
+func FindVM(ctx context.Context, ref string) (VMInfo, error) {
+	vms, err := listClusterVMs(ctx)
+	if err != nil {
+		return VMInfo{}, err
+	}
+
+	id, idErr := strconv.Atoi(ref)
+	for _, vm := range vms {
+		if (idErr == nil && vm.VMID == id) || strings.EqualFold(vm.Name, ref) {
+			return vm, nil
+		}
+	}
+	return VMInfo{}, fmt.Errorf("không tìm thấy VM %q", ref)
+}
+
+// ListNodes returns the names of all online cluster nodes
+func ListNodes(ctx context.Context) ([]string, error) {
+	client, err := NewHTTPClient(10*time.Second, PVETLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodesResp struct {
+		Data []struct {
+			Node   string `json:"node"`
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	if err := pveGet(ctx, client, PVEURL+"/nodes", pveAuthHeader(), &nodesResp); err != nil {
+		return nil, err
+	}
+
+	var nodes []string
+	for _, n := range nodesResp.Data {
+		if n.Status == "online" {
+			nodes = append(nodes, n.Node)
+		}
+	}
+	return nodes, nil
+}
+
+// MigrateVM migrates a guest to target and waits for the PVE task to finish.
+// Running VMs are migrated online; running containers use restart mode since LXC cannot live-migrate.
+// progress is called with the latest task log line whenever it changes. The migration is given
+// up to MigrationTimeout.
+func MigrateVM(ctx context.Context, vm VMInfo, target string, progress func(string)) MigrationResult {
+	ctx, cancel := context.WithTimeout(ctx, MigrationTimeout)
+	defer cancel()
+
+	result := MigrationResult{VM: vm, Target: target}
+	start := time.Now()
+	// Every return, early errors included, reports how long the attempt took
+	done := func() MigrationResult {
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	if vm.Node == target {
+		result.Error = fmt.Sprintf("%s đã ở trên %s", vm.Name, target)
+		return done()
+	}
+
+	client, err := NewHTTPClient(10*time.Second, PVETLS)
+	if err != nil {
+		result.Error = err.Error()
+		return done()
+	}
+
+	form := url.Values{"target": {target}}
+	if vm.Status == "running" {
+		if vm.Type == "qemu" {
+			form.Set("online", "1")
+			result.Online = true
+		} else {
+			form.Set("restart", "1")
+		}
+	}
+
+	var migrateResp struct {
+		Data string `json:"data"` // UPID of the migration task
+	}
+	path := fmt.Sprintf("%s/nodes/%s/%s/%d/migrate", PVEURL, vm.Node, vm.Type, vm.VMID)
+	if err := pvePost(ctx, client, path, pveAuthHeader(), form, &migrateResp); err != nil {
+		result.Error = err.Error()
+		return done()
+	}
+
+	if err := waitForTask(ctx, client, vm.Node, migrateResp.Data, progress); err != nil {
+		result.Error = err.Error()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.TimedOut = true
+			result.Error = fmt.Sprintf("quá %s, task PVE có thể vẫn đang chạy (%s)", MigrationTimeout, migrateResp.Data)
+		}
+	}
+	return done()
+}
+
+// DrainNode migrates every guest on node to the other online nodes, one at a time with
+// MigrationTimeout each. If target is empty, guests are spread round-robin across the remaining
+// nodes. A guest still migrating at its timeout (or a cancelled ctx) stops the drain: the results
+// so far are returned with an error naming that guest.
+func DrainNode(ctx context.Context, node, target string, progress func(string)) ([]MigrationResult, error) {
+	targets := []string{target}
+	if target == "" {
+		nodes, err := ListNodes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		targets = nil
+		for _, n := range nodes {
+			if n != node {
+				targets = append(targets, n)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("không có node đích nào khác %s", node)
+	}
+
+	vms, err := listClusterVMs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MigrationResult
+	i := 0
+	for _, vm := range vms {
+		if vm.Node != node {
+			continue
+		}
+		dst := targets[i%len(targets)]
+		i++
+
+		if progress != nil {
+			progress(fmt.Sprintf("[%d] %s → %s", i, vm.Name, dst))
+		}
+		result := MigrateVM(ctx, vm, dst, progress)
+		results = append(results, result)
+		if result.TimedOut {
+			return results, fmt.Errorf("đã dừng: %s (%d) vẫn đang di chuyển sang %s sau %s", vm.Name, vm.VMID, dst, MigrationTimeout)
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// listClusterVMs returns every guest in the cluster, unfiltered
+func listClusterVMs(ctx context.Context) ([]VMInfo, error) {
+	client, err := NewHTTPClient(10*time.Second, PVETLS)
+	if err != nil {
+		return nil, err
+	}
+
+	var vmsResp struct {
+		Data []struct {
+			VMID   int    `json:"vmid"`
+			Name   string `json:"name"`
+			Node   string `json:"node"`
+			Type   string `json:"type"`
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	if err := pveGet(ctx, client, PVEURL+"/cluster/resources?type=vm", pveAuthHeader(), &vmsResp); err != nil {
+		return nil, err
+	}
+
+	vms := make([]VMInfo, 0, len(vmsResp.Data))
+	for _, vm := range vmsResp.Data {
+		vms = append(vms, VMInfo{VMID: vm.VMID, Name: vm.Name, Node: vm.Node, Type: vm.Type, Status: vm.Status})
+	}
+	return vms, nil
+}
+
+// waitForTask polls a PVE task until it stops, reporting new log lines through progress
+func waitForTask(ctx context.Context, client *http.Client, node, upid string, progress func(string)) error {
+	taskURL := fmt.Sprintf("%s/nodes/%s/tasks/%s", PVEURL, node, url.PathEscape(upid))
+	seen := 0
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if progress != nil {
+			var logResp struct {
+				Data []struct {
+					T string `json:"t"`
+				} `json:"data"`
+			}
+			// Fetch only the lines appended since the last poll
+			logURL := fmt.Sprintf("%s/log?start=%d&limit=500", taskURL, seen)
+			if err := pveGet(ctx, client, logURL, pveAuthHeader(), &logResp); err == nil && len(logResp.Data) > 0 {
+				seen += len(logResp.Data)
+				progress(logResp.Data[len(logResp.Data)-1].T)
+			}
+		}
+
+		var statusResp struct {
+			Data struct {
+				Status     string `json:"status"`
+				ExitStatus string `json:"exitstatus"`
+			} `json:"data"`
+		}
+		if err := pveGet(ctx, client, taskURL+"/status", pveAuthHeader(), &statusResp); err != nil {
+			return err
+		}
+		if statusResp.Data.Status == "stopped" {
+			if statusResp.Data.ExitStatus != "OK" {
+				return fmt.Errorf("task failed: %s", statusResp.Data.ExitStatus)
+			}
+			return nil
+		}
+	}
+}
+
+// pveAuthHeader builds the API token header from config
+func pveAuthHeader() string {
+	return fmt.Sprintf("PVEAPIToken=%s!%s=%s", PVEUser, PVETokenName, PVETokenValue)
+}
+
+// pvePost performs an authenticated form POST against the Proxmox API and decodes the JSON body
+func pvePost(ctx context.Context, client *http.Client, endpoint, authHeader string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authHeader)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxmox API status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// ThrottleProgress wraps fn so it fires at most once per interval; intermediate messages are dropped
+func ThrottleProgress(interval time.Duration, fn func(string)) func(string) {
+	var last time.Time
+	return func(msg string) {
+		if time.Since(last) < interval {
+			return
+		}
+		last = time.Now()
+		fn(msg)
+	}
+}
+
+// FormatMigrationSummary renders migration results as plain lines shared by both bots
+func FormatMigrationSummary(results []MigrationResult) string {
+	if len(results) == 0 {
+		return "Không có máy nào cần di chuyển"
+	}
+
+	var sb strings.Builder
+	ok := 0
+	for _, r := range results {
+		mode := "offline"
+		if r.Online {
+			mode = "online"
+		}
+		if r.Error != "" {
+			sb.WriteString(fmt.Sprintf("❌ %s (%d) → %s: %s\n", r.VM.Name, r.VM.VMID, r.Target, r.Error))
+			continue
+		}
+		ok++
+		sb.WriteString(fmt.Sprintf("✅ %s (%d) → %s [%s] trong %s\n", r.VM.Name, r.VM.VMID, r.Target, mode, r.Duration.Round(time.Second)))
+	}
+	sb.WriteString(fmt.Sprintf("Hoàn tất %d/%d", ok, len(results)))
+	return sb.String()
+}
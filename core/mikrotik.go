@@ -3,26 +3,50 @@ package core
 import (
 	"context"
 	"fmt"
-	"time"
-
-	"github.com/gosnmp/gosnmp"
 )
 
-// GetMikroTikInfo fetches MikroTik information via SNMP using goroutine
+// GetMikroTikInfo fetches MikroTik information via SNMP, the RouterOS API or both (MIKROTIK_COLLECTOR)
 func GetMikroTikInfo(ctx context.Context, resultChan chan<- MikroTikInfo) {
 	defer close(resultChan)
 
-	// Initialize SNMP client
-	snmp := &gosnmp.GoSNMP{
-		Target:    MikroTikIP,
-		Port:      161,
-		Community: SNMPCommunity,
-		Version:   gosnmp.Version2c,
-		Timeout:   time.Duration(5) * time.Second,
-		Retries:   3,
+	switch MikroTikCollector {
+	case "api":
+		info, err := getRouterOSInfo(ctx)
+		if err != nil {
+			info.Error = err.Error()
+		}
+		resultChan <- info
+		return
+
+	case "both":
+		snmpChan := make(chan MikroTikInfo, 1)
+		go getSNMPMikroTikInfo(ctx, snmpChan)
+		apiInfo, apiErr := getRouterOSInfo(ctx)
+		info := <-snmpChan
+		if info.Error != "" && apiErr == nil {
+			// SNMP failed but the API answered: show the API view instead of an error
+			apiInfo.Error = ""
+			resultChan <- apiInfo
+			return
+		}
+		if apiErr == nil {
+			info = mergeRouterOSInfo(info, apiInfo)
+		}
+		resultChan <- info
+		return
 	}
 
-	err := snmp.Connect()
+	snmpChan := make(chan MikroTikInfo, 1)
+	getSNMPMikroTikInfo(ctx, snmpChan)
+	resultChan <- <-snmpChan
+}
+
+// getSNMPMikroTikInfo fetches MikroTik information via SNMP
+func getSNMPMikroTikInfo(ctx context.Context, resultChan chan<- MikroTikInfo) {
+	defer close(resultChan)
+
+	// Initialize SNMP client
+	snmp, err := NewSNMPClient(MikroTikSNMP)
 	if err != nil {
 		resultChan <- MikroTikInfo{Error: err.Error()}
 		return
@@ -77,6 +101,11 @@ func GetMikroTikInfo(ctx context.Context, resultChan chan<- MikroTikInfo) {
 		}
 	}
 
+	// Health sensors are optional: boards without them simply show nothing
+	if sensors, err := getSNMPHealth(snmp); err == nil {
+		info.Health = sensors
+	}
+
 	resultChan <- info
 }
 
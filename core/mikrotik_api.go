@@ -0,0 +1,161 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DialMikroTikAPI opens a RouterOS API session using the MIKROTIK_API_* settings
+func DialMikroTikAPI(ctx context.Context) (*RouterOSClient, error) {
+	if MikroTikAPI.User == "" {
+		return nil, fmt.Errorf("RouterOS API not configured (MIKROTIK_API_USER)")
+	}
+	return DialRouterOS(ctx, MikroTikAPI)
+}
+
+// getRouterOSInfo collects router information over the RouterOS API
+func getRouterOSInfo(ctx context.Context) (MikroTikInfo, error) {
+	api, err := DialMikroTikAPI(ctx)
+	if err != nil {
+		return MikroTikInfo{}, err
+	}
+	defer api.Close()
+
+	info := MikroTikInfo{Name: "N/A", CPU: "0", RAM: "N/A", Uptime: "N/A"}
+
+	if rows, err := api.Run("/system/identity/print"); err == nil && len(rows) > 0 {
+		info.Name = rows[0]["name"]
+	}
+
+	rows, err := api.Run("/system/resource/print")
+	if err != nil {
+		return info, err
+	}
+	if len(rows) > 0 {
+		res := rows[0]
+		info.CPU = res["cpu-load"]
+		info.Uptime = res["uptime"]
+		info.Version = res["version"]
+		info.Board = res["board-name"]
+		free, _ := strconv.ParseUint(res["free-memory"], 10, 64)
+		total, _ := strconv.ParseUint(res["total-memory"], 10, 64)
+		if total > 0 {
+			info.RAM = fmt.Sprintf("%d/%d MB", (total-free)/1048576, total/1048576)
+		}
+	}
+
+	// The remaining menus are optional extras; a failure only drops that part
+	if rows, err := api.Run("/interface/print", "=.proplist=name,type,running,disabled"); err == nil {
+		for _, row := range rows {
+			info.Interfaces = append(info.Interfaces, RouterInterface{
+				Name:     row["name"],
+				Type:     row["type"],
+				Running:  row["running"] == "true",
+				Disabled: row["disabled"] == "true",
+			})
+		}
+	}
+
+	if rows, err := api.Run("/ip/address/print", "=.proplist=address,interface,disabled"); err == nil {
+		for _, row := range rows {
+			if row["disabled"] == "true" {
+				continue
+			}
+			info.Addresses = append(info.Addresses, IPAddress{Address: row["address"], Interface: row["interface"]})
+		}
+	}
+
+	if rows, err := api.Run("/ppp/active/print"); err == nil {
+		for _, row := range rows {
+			info.PPPActive = append(info.PPPActive, PPPSession{
+				Name:    row["name"],
+				Service: row["service"],
+				Address: row["address"],
+				Uptime:  row["uptime"],
+			})
+		}
+	}
+
+	if rows, err := api.Run("/system/health/print"); err == nil {
+		info.Health = parseHealthRows(rows)
+	}
+
+	return info, nil
+}
+
+// parseHealthRows handles both RouterOS 7 (one row per sensor with name/value/type)
+// and RouterOS 6 (a single row with one attribute per sensor)
+func parseHealthRows(rows []map[string]string) []HealthSensor {
+	var sensors []HealthSensor
+	for _, row := range rows {
+		if name, ok := row["name"]; ok {
+			n, _ := strconv.ParseFloat(row["value"], 64)
+			sensors = append(sensors, HealthSensor{Name: name, Value: row["value"], Unit: row["type"], Number: n})
+			continue
+		}
+		for key, value := range row {
+			if strings.HasPrefix(key, ".") {
+				continue
+			}
+			n, _ := strconv.ParseFloat(value, 64)
+			sensors = append(sensors, HealthSensor{Name: key, Value: value, Unit: guessHealthUnit(key), Number: n})
+		}
+	}
+	sort.Slice(sensors, func(i, j int) bool { return sensors[i].Name < sensors[j].Name })
+	return sensors
+}
+
+func guessHealthUnit(key string) string {
+	switch {
+	case strings.Contains(key, "temperature"):
+		return "C"
+	case strings.Contains(key, "voltage"):
+		return "V"
+	case strings.Contains(key, "current"):
+		return "mA"
+	case strings.Contains(key, "power"):
+		return "W"
+	case strings.Contains(key, "fan"):
+		return "RPM"
+	}
+	return ""
+}
+
+// mergeRouterOSInfo fills fields SNMP cannot provide from an API result
+func mergeRouterOSInfo(snmp, api MikroTikInfo) MikroTikInfo {
+	snmp.Version = api.Version
+	snmp.Board = api.Board
+	snmp.Interfaces = api.Interfaces
+	snmp.Addresses = api.Addresses
+	snmp.PPPActive = api.PPPActive
+	if len(snmp.Health) == 0 {
+		snmp.Health = api.Health
+	}
+	return snmp
+}
+
+// FormatHealth renders health sensors on one line, e.g. "temperature 45C · voltage 24.1V"
+func FormatHealth(sensors []HealthSensor) string {
+	parts := make([]string, 0, len(sensors))
+	for _, s := range sensors {
+		parts = append(parts, fmt.Sprintf("%s %s%s", s.Name, s.Value, s.Unit))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// RunningInterfaces counts enabled interfaces that are running, and all enabled interfaces
+func RunningInterfaces(ifaces []RouterInterface) (running, enabled int) {
+	for _, iface := range ifaces {
+		if iface.Disabled {
+			continue
+		}
+		enabled++
+		if iface.Running {
+			running++
+		}
+	}
+	return running, enabled
+}
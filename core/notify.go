@@ -0,0 +1,35 @@
+package core
+
+import (
+	"log"
+	"sync"
+)
+
+// notifiers are the chat senders registered by the running bots
+var notifiers = struct {
+	sync.Mutex
+	list []func(string)
+}{}
+
+// RegisterNotifier adds a function that posts a message to a chat (Discord channel, Telegram chat...)
+func RegisterNotifier(send func(text string)) {
+	notifiers.Lock()
+	notifiers.list = append(notifiers.list, send)
+	notifiers.Unlock()
+}
+
+// Notify posts text to every registered chat
+func Notify(text string) {
+	notifiers.Lock()
+	list := make([]func(string), len(notifiers.list))
+	copy(list, notifiers.list)
+	notifiers.Unlock()
+
+	if len(list) == 0 {
+		log.Println("🔔 (no notifier registered)", text)
+		return
+	}
+	for _, send := range list {
+		send(text)
+	}
+}
@@ -2,12 +2,12 @@ package core
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -15,17 +15,15 @@ import (
 func GetProxmoxInfo(ctx context.Context, resultChan chan<- ProxmoxInfo) {
 	defer close(resultChan)
 
-	// Create HTTP client with timeout and skip SSL verification
-	client := &http.Client{
-		Timeout: 3 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+	// Create HTTP client with the configured TLS verification mode
+	client, err := NewHTTPClient(3*time.Second, PVETLS)
+	if err != nil {
+		resultChan <- ProxmoxInfo{Error: err.Error()}
+		return
 	}
 
-	// Build API URL
-	baseURL := fmt.Sprintf("https://%s:8006/api2/json", PVEHost)
-	authHeader := fmt.Sprintf("PVEAPIToken=%s!%s=%s", PVEUser, PVETokenName, PVETokenValue)
+	baseURL := PVEURL
+	authHeader := pveAuthHeader()
 
 	// Get nodes
 	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/nodes", nil)
@@ -77,9 +75,13 @@ func GetProxmoxInfo(ctx context.Context, resultChan chan<- ProxmoxInfo) {
 	body2, _ := io.ReadAll(resp2.Body)
 	var vmsResp struct {
 		Data []struct {
+			VMID   int    `json:"vmid"`
 			Name   string `json:"name"`
+			Node   string `json:"node"`
 			Type   string `json:"type"`
 			Status string `json:"status"`
+			Pool   string `json:"pool"`
+			Tags   string `json:"tags"`
 		} `json:"data"`
 	}
 
@@ -91,21 +93,138 @@ func GetProxmoxInfo(ctx context.Context, resultChan chan<- ProxmoxInfo) {
 	// Convert to VMInfo
 	vms := make([]VMInfo, 0, len(vmsResp.Data))
 	for _, vm := range vmsResp.Data {
+		var tags []string
+		if vm.Tags != "" {
+			// PVE separates tags with ";" (older versions also allow "," or spaces)
+			tags = strings.FieldsFunc(vm.Tags, func(r rune) bool {
+				return r == ';' || r == ',' || r == ' '
+			})
+		}
 		vms = append(vms, VMInfo{
+			VMID:   vm.VMID,
 			Name:   vm.Name,
+			Node:   vm.Node,
 			Type:   vm.Type,
 			Status: vm.Status,
+			Pool:   vm.Pool,
+			Tags:   tags,
 		})
 	}
+	vms = FilterVMs(vms, PVEFilter)
 
 	// Sort by name
 	sort.Slice(vms, func(i, j int) bool {
 		return vms[i].Name < vms[j].Name
 	})
 
-	resultChan <- ProxmoxInfo{
+	info := ProxmoxInfo{
 		Node:   nodeName,
 		Uptime: uptimeStr,
 		VMs:    vms,
 	}
+
+	// Host metrics are best-effort: a failure here should not hide the VM list
+	if status, err := getNodeStatus(ctx, client, baseURL, authHeader, nodeName); err == nil {
+		info.Host = status
+	} else {
+		info.Host.Error = err.Error()
+	}
+
+	// Pending updates require Sys.Audit on the node, so only show them if readable
+	if updates, err := getPendingUpdates(ctx, client, baseURL, authHeader, nodeName); err == nil {
+		info.Host.PendingUpdates = updates
+		info.Host.UpdatesChecked = true
+	}
+
+	resultChan <- info
+}
+
+// getNodeStatus reads CPU, load, memory, swap, rootfs and version info from /nodes/{node}/status
+func getNodeStatus(ctx context.Context, client *http.Client, baseURL, authHeader, node string) (NodeStatus, error) {
+	var statusResp struct {
+		Data struct {
+			CPU     float64  `json:"cpu"`
+			LoadAvg []string `json:"loadavg"`
+			Memory  struct {
+				Used  uint64 `json:"used"`
+				Total uint64 `json:"total"`
+			} `json:"memory"`
+			Swap struct {
+				Used  uint64 `json:"used"`
+				Total uint64 `json:"total"`
+			} `json:"swap"`
+			RootFS struct {
+				Used  uint64 `json:"used"`
+				Total uint64 `json:"total"`
+			} `json:"rootfs"`
+			CPUInfo struct {
+				CPUs int `json:"cpus"`
+			} `json:"cpuinfo"`
+			KVersion   string `json:"kversion"`
+			PVEVersion string `json:"pveversion"`
+		} `json:"data"`
+	}
+
+	if err := pveGet(ctx, client, baseURL+"/nodes/"+node+"/status", authHeader, &statusResp); err != nil {
+		return NodeStatus{}, err
+	}
+
+	d := statusResp.Data
+	status := NodeStatus{
+		CPU:        roundFloat(d.CPU*100, 1),
+		CPUs:       d.CPUInfo.CPUs,
+		LoadAvg:    strings.Join(d.LoadAvg, " "),
+		MemUsed:    d.Memory.Used,
+		MemTotal:   d.Memory.Total,
+		SwapUsed:   d.Swap.Used,
+		SwapTotal:  d.Swap.Total,
+		RootUsed:   d.RootFS.Used,
+		RootTotal:  d.RootFS.Total,
+		Kernel:     d.KVersion,
+		PVEVersion: d.PVEVersion,
+	}
+
+	// "Linux 6.8.12-4-pve #1 SMP ..." -> "6.8.12-4-pve"
+	if fields := strings.Fields(d.KVersion); len(fields) >= 2 {
+		status.Kernel = fields[1]
+	}
+	// "pve-manager/8.2.4/faa83925c9641325" -> "8.2.4"
+	if parts := strings.Split(d.PVEVersion, "/"); len(parts) >= 2 {
+		status.PVEVersion = parts[1]
+	}
+
+	return status, nil
+}
+
+// getPendingUpdates counts packages listed by /nodes/{node}/apt/update
+func getPendingUpdates(ctx context.Context, client *http.Client, baseURL, authHeader, node string) (int, error) {
+	var aptResp struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := pveGet(ctx, client, baseURL+"/nodes/"+node+"/apt/update", authHeader, &aptResp); err != nil {
+		return 0, err
+	}
+	return len(aptResp.Data), nil
+}
+
+// pveGet performs an authenticated GET against the Proxmox API and decodes the JSON body
+func pveGet(ctx context.Context, client *http.Client, url, authHeader string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authHeader)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("proxmox API status %d", resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	return json.Unmarshal(body, out)
 }
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// oidIpAdEntIfIndex maps each router address to its ifIndex (IP-MIB ipAddrTable)
+const oidIpAdEntIfIndex = "1.3.6.1.2.1.4.20.1.2"
+
+// publicIP remembers the last observed WAN address
+var publicIP = struct {
+	sync.Mutex
+	current   string
+	changedAt time.Time
+}{}
+
+// CurrentPublicIP returns the last known WAN address and when it last changed
+func CurrentPublicIP() (string, time.Time) {
+	publicIP.Lock()
+	defer publicIP.Unlock()
+	return publicIP.current, publicIP.changedAt
+}
+
+// RecordPublicIP stores a newly observed address and notifies both chats when it differs
+// from the previous one. The first observation after startup is recorded silently.
+func RecordPublicIP(ip string) {
+	if ip == "" {
+		return
+	}
+
+	publicIP.Lock()
+	old := publicIP.current
+	if old == ip {
+		publicIP.Unlock()
+		return
+	}
+	publicIP.current = ip
+	publicIP.changedAt = time.Now()
+	publicIP.Unlock()
+
+	if old != "" {
+		log.Printf("🌍 Public IP changed: %s -> %s", old, ip)
+		Notify(fmt.Sprintf("🌍 IP WAN đã thay đổi: %s → %s (lúc %s)", old, ip, GetVietnamTime()))
+	}
+}
+
+// LookupPublicIP reads the WAN address from the router (PUBLIC_IP_SOURCE=router) or an HTTP echo service
+func LookupPublicIP(ctx context.Context) (string, error) {
+	if PublicIPSource != "router" {
+		return lookupPublicIPHTTP(ctx, PublicIPSource)
+	}
+
+	// Prefer the RouterOS API when configured, otherwise use SNMP ipAddrTable
+	if MikroTikAPI.User != "" {
+		api, err := DialMikroTikAPI(ctx)
+		if err != nil {
+			return "", err
+		}
+		defer api.Close()
+		ip, err := interfaceAddress(api, PPPoEInterface)
+		if err == nil && ip == "" {
+			err = fmt.Errorf("%s has no address", PPPoEInterface)
+		}
+		return ip, err
+	}
+	return lookupPublicIPSNMP(ctx)
+}
+
+// lookupPublicIPSNMP finds the address whose ipAdEntIfIndex is the PPPoE interface
+func lookupPublicIPSNMP(ctx context.Context) (string, error) {
+	index, err := ResolveInterface(ctx, PPPoEInterface)
+	if err != nil {
+		return "", err
+	}
+
+	snmp, err := NewSNMPClient(MikroTikSNMP)
+	if err != nil {
+		return "", err
+	}
+	defer snmp.Conn.Close()
+	snmp.Context = ctx
+
+	pdus, err := snmp.BulkWalkAll(oidIpAdEntIfIndex)
+	if err != nil {
+		return "", err
+	}
+	for _, pdu := range pdus {
+		if int(gosnmp.ToBigInt(pdu.Value).Int64()) != index {
+			continue
+		}
+		// The table index is the address itself: ...4.20.1.2.A.B.C.D
+		parts := strings.Split(strings.TrimPrefix(pdu.Name, "."), ".")
+		if len(parts) >= 4 {
+			return strings.Join(parts[len(parts)-4:], "."), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no address", PPPoEInterface)
+}
+
+// lookupPublicIPHTTP queries a plain-text "what is my IP" service such as https://api.ipify.org
+func lookupPublicIPHTTP(ctx context.Context, url string) (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("unexpected response from %s: %q", url, ip)
+	}
+	return ip, nil
+}
+
+// StartPublicIPWatcher polls the WAN address every PUBLIC_IP_INTERVAL and notifies on change
+func StartPublicIPWatcher() {
+	go func() {
+		ticker := time.NewTicker(PublicIPInterval)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			ip, err := LookupPublicIP(ctx)
+			cancel()
+			if err != nil {
+				log.Printf("⚠️  Public IP lookup failed: %v", err)
+				continue
+			}
+			RecordPublicIP(ip)
+		}
+	}()
+}
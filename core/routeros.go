@@ -0,0 +1,250 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouterOSConfig holds connection settings for the RouterOS API (port 8728, or 8729 with TLS)
+type RouterOSConfig struct {
+	Host     string
+	Port     string
+	UseTLS   bool
+	TLS      TLSOptions
+	User     string
+	Password string
+	Timeout  time.Duration
+}
+
+// RouterOSClient is a minimal synchronous RouterOS API client (one command in flight at a time)
+type RouterOSClient struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+	ctx     context.Context // Dial context: bounds every Run and aborts it when cancelled
+	stop    func() bool     // Unregisters the cancellation hook
+}
+
+// RouterOSError is a !trap reply returned by the router
+type RouterOSError struct {
+	Message string
+}
+
+func (e *RouterOSError) Error() string {
+	return "routeros: " + e.Message
+}
+
+// DialRouterOS connects and logs in to the RouterOS API
+func DialRouterOS(ctx context.Context, cfg RouterOSConfig) (*RouterOSClient, error) {
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+
+	var conn net.Conn
+	var err error
+	if cfg.UseTLS {
+		tlsConfig, tlsErr := cfg.TLS.tlsConfig()
+		if tlsErr != nil {
+			return nil, tlsErr
+		}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &RouterOSClient{conn: conn, r: bufio.NewReader(conn), timeout: cfg.Timeout, ctx: ctx}
+	// Unblock a pending read or write as soon as the caller gives up
+	c.stop = context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	if err := c.login(cfg.User, cfg.Password); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close terminates the API session
+func (c *RouterOSClient) Close() error {
+	c.stop()
+	return c.conn.Close()
+}
+
+// Run sends a command such as "/interface/print" with optional "=key=value" or "?query" words
+// and returns the attributes of every !re reply.
+func (c *RouterOSClient) Run(command string, args ...string) ([]map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	// MIKROTIK_API_TIMEOUT per command, but never past the dial context's deadline
+	var deadline time.Time
+	if c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+	if d, ok := c.ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+	if err := c.writeSentence(append([]string{command}, args...)); err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]string
+	var trap error
+	for {
+		reply, attrs, err := c.readSentence()
+		if err != nil {
+			return nil, err
+		}
+		switch reply {
+		case "!re":
+			rows = append(rows, attrs)
+		case "!trap":
+			trap = &RouterOSError{Message: attrs["message"]}
+		case "!fatal":
+			return nil, &RouterOSError{Message: "fatal: " + attrs["message"]}
+		case "!done":
+			if trap != nil {
+				return nil, trap
+			}
+			// !done may itself carry attributes (e.g. =ret= from /login or add commands)
+			if len(attrs) > 0 {
+				rows = append(rows, attrs)
+			}
+			return rows, nil
+		}
+	}
+}
+
+// login supports both the plain login (RouterOS 6.43+) and the legacy MD5 challenge
+func (c *RouterOSClient) login(user, password string) error {
+	rows, err := c.Run("/login", "=name="+user, "=password="+password)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 || rows[0]["ret"] == "" {
+		return nil
+	}
+	challenge, err := hex.DecodeString(rows[0]["ret"])
+	if err != nil {
+		return fmt.Errorf("routeros: bad login challenge: %w", err)
+	}
+	h := md5.New()
+	h.Write([]byte{0})
+	h.Write([]byte(password))
+	h.Write(challenge)
+	_, err = c.Run("/login", "=name="+user, "=response=00"+hex.EncodeToString(h.Sum(nil)))
+	return err
+}
+
+func (c *RouterOSClient) writeSentence(words []string) error {
+	var buf []byte
+	for _, w := range words {
+		buf = append(buf, encodeLength(len(w))...)
+		buf = append(buf, w...)
+	}
+	buf = append(buf, 0)
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// readSentence returns the reply word (!re, !done, ...) and its =key=value attributes
+func (c *RouterOSClient) readSentence() (string, map[string]string, error) {
+	reply := ""
+	attrs := make(map[string]string)
+	for {
+		word, err := c.readWord()
+		if err != nil {
+			return "", nil, err
+		}
+		if word == "" {
+			return reply, attrs, nil
+		}
+		if reply == "" {
+			reply = word
+			continue
+		}
+		if strings.HasPrefix(word, "=") {
+			kv := strings.SplitN(word[1:], "=", 2)
+			if len(kv) == 2 {
+				attrs[kv[0]] = kv[1]
+			}
+		}
+	}
+}
+
+func (c *RouterOSClient) readWord() (string, error) {
+	n, err := c.readLength()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readLength decodes the variable-length word prefix used by the API protocol
+func (c *RouterOSClient) readLength() (int, error) {
+	b, err := c.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	var extra int
+	var n int
+	switch {
+	case b&0x80 == 0x00:
+		return int(b), nil
+	case b&0xC0 == 0x80:
+		n, extra = int(b&0x3F), 1
+	case b&0xE0 == 0xC0:
+		n, extra = int(b&0x1F), 2
+	case b&0xF0 == 0xE0:
+		n, extra = int(b&0x0F), 3
+	case b == 0xF0:
+		n, extra = 0, 4
+	default:
+		return 0, errors.New("routeros: invalid length prefix")
+	}
+
+	for i := 0; i < extra; i++ {
+		next, err := c.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = n<<8 | int(next)
+	}
+	return n, nil
+}
+
+func encodeLength(n int) []byte {
+	switch {
+	case n < 0x80:
+		return []byte{byte(n)}
+	case n < 0x4000:
+		return []byte{byte(n>>8) | 0x80, byte(n)}
+	case n < 0x200000:
+		return []byte{byte(n>>16) | 0xC0, byte(n >> 8), byte(n)}
+	case n < 0x10000000:
+		return []byte{byte(n>>24) | 0xE0, byte(n >> 16), byte(n >> 8), byte(n)}
+	default:
+		return []byte{0xF0, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+}
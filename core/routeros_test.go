@@ -0,0 +1,50 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestEncodeLength(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x80, 0x80}},
+		{0x3FFF, []byte{0xBF, 0xFF}},
+		{0x4000, []byte{0xC0, 0x40, 0x00}},
+		{0x1FFFFF, []byte{0xDF, 0xFF, 0xFF}},
+		{0x200000, []byte{0xE0, 0x20, 0x00, 0x00}},
+		{0xFFFFFFF, []byte{0xEF, 0xFF, 0xFF, 0xFF}},
+		{0x10000000, []byte{0xF0, 0x10, 0x00, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		got := encodeLength(tt.n)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("encodeLength(%#x) = % x, want % x", tt.n, got, tt.want)
+		}
+
+		c := &RouterOSClient{r: bufio.NewReader(bytes.NewReader(got))}
+		n, err := c.readLength()
+		if err != nil || n != tt.n {
+			t.Errorf("readLength(% x) = %#x, %v; want %#x", got, n, err, tt.n)
+		}
+	}
+}
+
+func TestReadLengthErrors(t *testing.T) {
+	for _, input := range [][]byte{
+		{},                 // no prefix at all
+		{0xF8},             // reserved control byte
+		{0x80},             // two-byte length cut short
+		{0xF0, 0x01, 0x02}, // five-byte length cut short
+	} {
+		c := &RouterOSClient{r: bufio.NewReader(bytes.NewReader(input))}
+		if n, err := c.readLength(); err == nil {
+			t.Errorf("readLength(% x) = %d, expected an error", input, n)
+		}
+	}
+}
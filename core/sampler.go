@@ -0,0 +1,380 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// oidSysUpTime is used to detect router reboots between samples
+const oidSysUpTime = "1.3.6.1.2.1.1.3.0"
+
+// The sampler polls every SAMPLE_INTERVAL, so a lost packet must not stall it for the
+// shared client's full retry budget; the next tick is a retry anyway
+const (
+	samplerTimeout = 2 * time.Second
+	samplerRetries = 1
+)
+
+// RateWindow is the throughput of one interface over a time window
+type RateWindow struct {
+	Window time.Duration
+	RxAvg  float64 // Mbps
+	TxAvg  float64
+	RxPeak float64 // Highest single-interval rate inside the window
+	TxPeak float64
+	Errors [4]uint64 // In errors, out errors, in discards, out discards counted inside the window
+}
+
+// counterReading is one raw snapshot of an interface's octet and error counters
+type counterReading struct {
+	rx, tx uint64
+	errors [4]uint64 // in errors, out errors, in discards, out discards
+	at     time.Time
+}
+
+// rateSample is the traffic between two consecutive readings
+type rateSample struct {
+	at      time.Time
+	seconds float64
+	rxBytes float64
+	txBytes float64
+	errors  [4]uint64
+}
+
+// ifaceState is the sampler's per-interface memory
+type ifaceState struct {
+	prev        *counterReading
+	samples     []rateSample
+	err         string
+	lastHistory time.Time
+}
+
+// sampler keeps counter readings between polls so rates never need a blocking second sample
+var sampler = struct {
+	sync.Mutex
+	ifaces  map[string]*ifaceState
+	uptime  uint32
+	started bool
+}{ifaces: make(map[string]*ifaceState)}
+
+// StartSampler launches the background SNMP counter poller. Safe to call more than once.
+func StartSampler() {
+	sampler.Lock()
+	if sampler.started {
+		sampler.Unlock()
+		return
+	}
+	sampler.started = true
+	sampler.Unlock()
+
+	go func() {
+		WarmInterfaceCache()
+
+		var snmp *gosnmp.GoSNMP
+		ticker := time.NewTicker(SampleInterval)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			if snmp == nil {
+				target := MikroTikSNMP
+				target.Timeout = min(target.Timeout, samplerTimeout)
+				target.Retries = min(target.Retries, samplerRetries)
+				client, err := NewSNMPClient(target)
+				if err != nil {
+					setSamplerError(err.Error())
+					continue
+				}
+				snmp = client
+			}
+
+			if err := pollCounters(snmp); err != nil {
+				setSamplerError(err.Error())
+				snmp.Conn.Close()
+				snmp = nil
+			}
+		}
+	}()
+}
+
+// sampledInterfaces returns the PPPoE interface plus MONITOR_INTERFACES without duplicates
+func sampledInterfaces() []string {
+	names := []string{PPPoEInterface}
+	seen := map[string]bool{PPPoEInterface: true}
+	for _, name := range MonitorInterfaces {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// pollCounters reads sysUpTime plus octet/error counters for every sampled interface
+func pollCounters(snmp *gosnmp.GoSNMP) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	names := sampledInterfaces()
+	indexes := make(map[string]int, len(names))
+	oids := []string{oidSysUpTime}
+	for _, name := range names {
+		index, err := ResolveInterface(ctx, name)
+		if err != nil {
+			setInterfaceError(name, err.Error())
+			continue
+		}
+		indexes[name] = index
+		for _, column := range []string{oidIfHCInOctets, oidIfHCOutOctets, oidIfInErrors, oidIfOutErrors, oidIfInDiscards, oidIfOutDiscards} {
+			oids = append(oids, fmt.Sprintf("%s.%d", column, index))
+		}
+	}
+
+	values, err := snmpGetAll(snmp, oids)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	sampler.Lock()
+	defer sampler.Unlock()
+
+	// A lower sysUpTime means the router rebooted and every counter restarted from zero
+	if pdu, ok := values[oidSysUpTime]; ok && !snmpMissing(pdu) {
+		uptime := uint32(gosnmp.ToBigInt(pdu.Value).Uint64())
+		if uptime < sampler.uptime {
+			log.Println("📡 SNMP: router reboot detected, resetting counter baselines")
+			for _, state := range sampler.ifaces {
+				state.prev = nil
+			}
+		}
+		sampler.uptime = uptime
+	}
+
+	for name, index := range indexes {
+		state := sampler.ifaces[name]
+		if state == nil {
+			state = &ifaceState{}
+			sampler.ifaces[name] = state
+		}
+
+		rxPDU := values[fmt.Sprintf("%s.%d", oidIfHCInOctets, index)]
+		txPDU := values[fmt.Sprintf("%s.%d", oidIfHCOutOctets, index)]
+		if snmpMissing(rxPDU) || snmpMissing(txPDU) {
+			// The index vanished (interface recreated): look the name up again next poll
+			InvalidateInterface(name)
+			state.prev = nil
+			state.err = "interface index no longer exists"
+			continue
+		}
+
+		cur := counterReading{
+			rx: gosnmp.ToBigInt(rxPDU.Value).Uint64(),
+			tx: gosnmp.ToBigInt(txPDU.Value).Uint64(),
+			at: now,
+		}
+		if state.prev != nil {
+			// A counter the agent did not return counts as unchanged
+			cur.errors = state.prev.errors
+		}
+		for i, column := range []string{oidIfInErrors, oidIfOutErrors, oidIfInDiscards, oidIfOutDiscards} {
+			if pdu, ok := values[fmt.Sprintf("%s.%d", column, index)]; ok && !snmpMissing(pdu) {
+				cur.errors[i] = gosnmp.ToBigInt(pdu.Value).Uint64()
+			}
+		}
+		state.err = ""
+
+		if state.prev != nil {
+			rx, rxOK := counterDelta(state.prev.rx, cur.rx)
+			tx, txOK := counterDelta(state.prev.tx, cur.tx)
+			seconds := cur.at.Sub(state.prev.at).Seconds()
+			if rxOK && txOK && seconds > 0 {
+				sample := rateSample{at: now, seconds: seconds, rxBytes: float64(rx), txBytes: float64(tx)}
+				for i := range cur.errors {
+					// A reset counter adds nothing rather than its whole value
+					sample.errors[i], _ = counter32Delta(state.prev.errors[i], cur.errors[i])
+				}
+				state.samples = append(state.samples, sample)
+			}
+		}
+		state.prev = &cur
+		state.trim(now)
+
+		// Record one history point per minute using the 1-minute average
+		if now.Sub(state.lastHistory) >= time.Minute {
+			w := state.window(now, time.Minute)
+			RecordBandwidth(name, w.RxAvg, w.TxAvg)
+			state.lastHistory = now
+		}
+	}
+
+	return nil
+}
+
+// counterDelta returns cur-prev for a 64-bit counter. A decrease close to the top of
+// the range is a wrap; any other decrease is a reset, for which no delta exists.
+func counterDelta(prev, cur uint64) (uint64, bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	if prev > math.MaxUint64-math.MaxUint64/4 {
+		return (math.MaxUint64 - prev) + cur + 1, true
+	}
+	return 0, false
+}
+
+// counter32Delta is counterDelta for the 32-bit IF-MIB error and discard counters
+func counter32Delta(prev, cur uint64) (uint64, bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	if prev <= math.MaxUint32 && prev > math.MaxUint32-math.MaxUint32/4 {
+		return (math.MaxUint32 - prev) + cur + 1, true
+	}
+	return 0, false
+}
+
+// snmpGetAll issues as many Get requests as needed to stay under the per-PDU OID limit
+func snmpGetAll(snmp *gosnmp.GoSNMP, oids []string) (map[string]gosnmp.SnmpPDU, error) {
+	values := make(map[string]gosnmp.SnmpPDU, len(oids))
+	limit := snmp.MaxOids
+	if limit <= 0 {
+		limit = gosnmp.MaxOids
+	}
+	for start := 0; start < len(oids); start += limit {
+		end := start + limit
+		if end > len(oids) {
+			end = len(oids)
+		}
+		result, err := snmp.Get(oids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, pdu := range result.Variables {
+			// gosnmp returns names with a leading dot
+			name := pdu.Name
+			if len(name) > 0 && name[0] == '.' {
+				name = name[1:]
+			}
+			values[name] = pdu
+		}
+	}
+	return values, nil
+}
+
+// trim drops samples older than the largest configured window
+func (s *ifaceState) trim(now time.Time) {
+	keep := maxRateWindow()
+	i := 0
+	for i < len(s.samples) && now.Sub(s.samples[i].at) > keep {
+		i++
+	}
+	s.samples = s.samples[i:]
+}
+
+// window aggregates the samples that fall inside the last w of time
+func (s *ifaceState) window(now time.Time, w time.Duration) RateWindow {
+	rw := RateWindow{Window: w}
+	var seconds, rxBytes, txBytes float64
+
+	// Include a sample when most of its interval lies inside the window
+	for i := len(s.samples) - 1; i >= 0; i-- {
+		sample := s.samples[i]
+		if now.Sub(sample.at)+time.Duration(sample.seconds*float64(time.Second))/2 > w && seconds > 0 {
+			break
+		}
+		seconds += sample.seconds
+		rxBytes += sample.rxBytes
+		txBytes += sample.txBytes
+		rw.RxPeak = math.Max(rw.RxPeak, toMbps(sample.rxBytes, sample.seconds))
+		rw.TxPeak = math.Max(rw.TxPeak, toMbps(sample.txBytes, sample.seconds))
+		for j, n := range sample.errors {
+			rw.Errors[j] += n
+		}
+	}
+
+	rw.RxAvg = toMbps(rxBytes, seconds)
+	rw.TxAvg = toMbps(txBytes, seconds)
+	rw.RxPeak = roundFloat(rw.RxPeak, 2)
+	rw.TxPeak = roundFloat(rw.TxPeak, 2)
+	return rw
+}
+
+// GetRates returns the configured rate windows for an interface, shortest first
+func GetRates(name string) ([]RateWindow, error) {
+	sampler.Lock()
+	defer sampler.Unlock()
+
+	state := sampler.ifaces[name]
+	if state == nil {
+		if !sampler.started {
+			return nil, fmt.Errorf("sampler not running")
+		}
+		return nil, fmt.Errorf("waiting for first sample")
+	}
+	if state.err != "" {
+		return nil, fmt.Errorf("%s", state.err)
+	}
+	if len(state.samples) == 0 {
+		return nil, fmt.Errorf("waiting for first sample")
+	}
+
+	now := time.Now()
+	windows := make([]RateWindow, 0, len(RateWindows))
+	for _, w := range RateWindows {
+		windows = append(windows, state.window(now, w))
+	}
+	return windows, nil
+}
+
+// pickWindow returns the window matching d, or the first one if none does
+func pickWindow(windows []RateWindow, d time.Duration) RateWindow {
+	for _, w := range windows {
+		if w.Window == d {
+			return w
+		}
+	}
+	return windows[0]
+}
+
+// setSamplerError marks every sampled interface as failing until the next good poll
+func setSamplerError(msg string) {
+	for _, name := range sampledInterfaces() {
+		setInterfaceError(name, msg)
+	}
+}
+
+func setInterfaceError(name, msg string) {
+	sampler.Lock()
+	defer sampler.Unlock()
+
+	state := sampler.ifaces[name]
+	if state == nil {
+		state = &ifaceState{}
+		sampler.ifaces[name] = state
+	}
+	state.err = msg
+}
+
+func maxRateWindow() time.Duration {
+	max := time.Duration(0)
+	for _, w := range RateWindows {
+		if w > max {
+			max = w
+		}
+	}
+	return max
+}
+
+// toMbps converts bytes over seconds to Mbps (2^20 bits, matching the original PPPoE readout)
+func toMbps(bytes, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return roundFloat(bytes*8/1048576/seconds, 2)
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur uint64
+		want      uint64
+		ok        bool
+	}{
+		{"increase", 1000, 1500, 500, true},
+		{"unchanged", 42, 42, 0, true},
+		{"wrap by one", math.MaxUint64, 0, 1, true},
+		{"wrap", math.MaxUint64 - 9, 10, 20, true},
+		{"wrap from the top quarter", math.MaxUint64 - math.MaxUint64/4 + 1, 0, math.MaxUint64 / 4, true},
+		{"reset", 5_000_000, 100, 0, false},
+		{"reset below the top quarter", math.MaxUint64 / 2, 10, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := counterDelta(tt.prev, tt.cur)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: counterDelta(%d, %d) = %d, %v; want %d, %v", tt.name, tt.prev, tt.cur, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCounter32Delta(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur uint64
+		want      uint64
+		ok        bool
+	}{
+		{"increase", 10, 25, 15, true},
+		{"wrap by one", math.MaxUint32, 0, 1, true},
+		{"wrap", math.MaxUint32 - 4, 5, 10, true},
+		{"reset", 1000, 3, 0, false},
+		{"reset above 32 bits", math.MaxUint32 + 10, 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := counter32Delta(tt.prev, tt.cur)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: counter32Delta(%d, %d) = %d, %v; want %d, %v", tt.name, tt.prev, tt.cur, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScheduleEntry switches Group to Node whenever Spec matches (Vietnam time)
+type ScheduleEntry struct {
+	Spec  string // Five-field cron expression: minute hour day-of-month month day-of-week
+	Group string
+	Node  string
+	expr  cronExpr
+}
+
+// String renders the entry as written in NODE_SCHEDULE
+func (e ScheduleEntry) String() string {
+	return fmt.Sprintf("%s %s=%s", e.Spec, e.Group, e.Node)
+}
+
+// ScheduleSlot is the next planned switch of one group, shown on the dashboard
+type ScheduleSlot struct {
+	Group          string
+	Node           string
+	At             time.Time
+	SuspendedUntil time.Time // Set while a manual switch holds the group (NODE_SCHEDULE_MANUAL_SUSPEND)
+}
+
+// cronExpr holds the allowed values of each cron field as bit sets
+type cronExpr struct {
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool // "*" fields, needed for the day-of-month/day-of-week OR rule
+}
+
+// scheduleFields are the bounds of the five cron fields
+var scheduleFields = [5]struct{ min, max int }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// parseCron parses a standard five-field cron expression with lists, ranges and steps
+// ("*/15", "1-5", "0,30"). Day-of-week 7 is Sunday like 0.
+func parseCron(spec string) (cronExpr, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return cronExpr{}, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		for _, part := range strings.Split(field, ",") {
+			lo, hi, step := scheduleFields[i].min, scheduleFields[i].max, 1
+			rng := part
+			if j := strings.Index(part, "/"); j >= 0 {
+				n, err := strconv.Atoi(part[j+1:])
+				if err != nil || n <= 0 {
+					return cronExpr{}, fmt.Errorf("bad step in %q", part)
+				}
+				rng, step = part[:j], n
+			}
+			if rng != "*" {
+				bounds := strings.SplitN(rng, "-", 2)
+				var err error
+				if lo, err = strconv.Atoi(bounds[0]); err != nil {
+					return cronExpr{}, fmt.Errorf("bad value %q", part)
+				}
+				hi = lo
+				if len(bounds) == 2 {
+					if hi, err = strconv.Atoi(bounds[1]); err != nil {
+						return cronExpr{}, fmt.Errorf("bad value %q", part)
+					}
+				} else if step > 1 {
+					// "5/10" means from 5 to the end of the range
+					hi = scheduleFields[i].max
+				}
+			}
+			if lo < scheduleFields[i].min || hi > scheduleFields[i].max || lo > hi {
+				return cronExpr{}, fmt.Errorf("%q out of range %d-%d", part, scheduleFields[i].min, scheduleFields[i].max)
+			}
+			for v := lo; v <= hi; v += step {
+				sets[i] |= 1 << uint(v)
+			}
+		}
+	}
+
+	// Fold Sunday=7 into 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+	return cronExpr{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		anyDom: fields[2] == "*", anyDow: fields[4] == "*",
+	}, nil
+}
+
+// matchDay applies the cron rule that a restricted day-of-month and day-of-week are OR'ed
+func (c cronExpr) matchDay(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// next returns the first matching minute strictly after t, or zero if none within a year
+func (c cronExpr) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(1, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0 || !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// prev returns the last matching minute at or before t, or zero if none within the lookback
+func (c cronExpr) prev(t time.Time, lookback time.Duration) time.Time {
+	t = t.Truncate(time.Minute)
+	limit := t.Add(-lookback)
+	for !t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0 || !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(-time.Minute)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ParseNodeSchedule parses "cron group=node" entries (';' or newline separated). The group can be
+// left out ("0 18 * * * WG-SG") to use defaultGroup.
+func ParseNodeSchedule(s, defaultGroup string) ([]ScheduleEntry, error) {
+	var entries []ScheduleEntry
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("schedule entry %q: expected \"<cron> [group=]node\"", strings.TrimSpace(item))
+		}
+
+		spec := strings.Join(fields[:5], " ")
+		expr, err := parseCron(spec)
+		if err != nil {
+			return nil, fmt.Errorf("schedule entry %q: %w", strings.TrimSpace(item), err)
+		}
+		group, node := defaultGroup, fields[5]
+		if g, n, ok := strings.Cut(fields[5], "="); ok {
+			group, node = g, n
+		}
+		if group == "" || node == "" {
+			return nil, fmt.Errorf("schedule entry %q: missing group or node", strings.TrimSpace(item))
+		}
+		entries = append(entries, ScheduleEntry{Spec: spec, Group: group, Node: node, expr: expr})
+	}
+	return entries, nil
+}
+
+// scheduleLookback bounds the search for the slot currently in effect
+const scheduleLookback = 8 * 24 * time.Hour
+
+// schedule tracks, per group, the last slot the scheduler has acted on, the hold placed by a
+// manual switch and manual switches waiting to be reverted
+var schedule = struct {
+	sync.Mutex
+	applied   map[string]time.Time
+	suspended map[string]time.Time
+	revert    map[string]bool
+}{applied: make(map[string]time.Time), suspended: make(map[string]time.Time), revert: make(map[string]bool)}
+
+// activeEntry returns the entry of group whose slot fired most recently at or before now
+func activeEntry(group string, now time.Time) (ScheduleEntry, time.Time, bool) {
+	var (
+		best   ScheduleEntry
+		bestAt time.Time
+	)
+	for _, e := range NodeSchedule {
+		if e.Group != group {
+			continue
+		}
+		if at := e.expr.prev(now, scheduleLookback); !at.IsZero() && at.After(bestAt) {
+			best, bestAt = e, at
+		}
+	}
+	return best, bestAt, !bestAt.IsZero()
+}
+
+// nextSlot returns the earliest upcoming slot of group after now
+func nextSlot(group string, now time.Time) (ScheduleEntry, time.Time, bool) {
+	var (
+		best   ScheduleEntry
+		bestAt time.Time
+	)
+	for _, e := range NodeSchedule {
+		if e.Group != group {
+			continue
+		}
+		if at := e.expr.next(now); !at.IsZero() && (bestAt.IsZero() || at.Before(bestAt)) {
+			best, bestAt = e, at
+		}
+	}
+	return best, bestAt, !bestAt.IsZero()
+}
+
+// scheduleGroups lists the scheduled groups in NODE_SCHEDULE order
+func scheduleGroups() []string {
+	var groups []string
+	for _, e := range NodeSchedule {
+		if !containsExact(groups, e.Group) {
+			groups = append(groups, e.Group)
+		}
+	}
+	return groups
+}
+
+// noteManualSwitch records a manual switch of group. With NODE_SCHEDULE_MANUAL_SUSPEND it is
+// kept until the next slot, which the dashboard shows; otherwise the next check reverts it.
+func noteManualSwitch(group string) {
+	_, at, ok := nextSlot(group, time.Now().In(vietnamTZ))
+	if !ok {
+		return
+	}
+	schedule.Lock()
+	defer schedule.Unlock()
+	if NodeScheduleManualSuspend {
+		schedule.suspended[group] = at
+	} else {
+		schedule.revert[group] = true
+	}
+}
+
+// takeScheduleEvent reports whether a new slot (at) of group has fired since the last check and
+// whether a manual switch is waiting to be reverted. The first check after startup only records
+// the slot in effect, so a restart does not undo manual or failover switches.
+func takeScheduleEvent(group string, at time.Time) (fired, revert bool) {
+	schedule.Lock()
+	defer schedule.Unlock()
+	last, seen := schedule.applied[group]
+	schedule.applied[group] = at
+	revert = schedule.revert[group]
+	delete(schedule.revert, group)
+	return seen && at.After(last), revert
+}
+
+// rearmScheduleEvent makes the next check retry an event that failed to apply
+func rearmScheduleEvent(group string, fired, revert bool) {
+	schedule.Lock()
+	defer schedule.Unlock()
+	if fired {
+		schedule.applied[group] = time.Time{}
+	}
+	if revert {
+		schedule.revert[group] = true
+	}
+}
+
+// suspendedUntil returns when the hold on group ends, or zero if it is not held
+func suspendedUntil(group string, now time.Time) time.Time {
+	schedule.Lock()
+	defer schedule.Unlock()
+	until, ok := schedule.suspended[group]
+	if ok && !now.Before(until) {
+		delete(schedule.suspended, group)
+		return time.Time{}
+	}
+	return until
+}
+
+// UpcomingSchedule returns the next slot of every scheduled group, soonest first
+func UpcomingSchedule() []ScheduleSlot {
+	now := time.Now().In(vietnamTZ)
+	var slots []ScheduleSlot
+	for _, group := range scheduleGroups() {
+		e, at, ok := nextSlot(group, now)
+		if !ok {
+			continue
+		}
+		slots = append(slots, ScheduleSlot{Group: group, Node: e.Node, At: at, SuspendedUntil: suspendedUntil(group, now)})
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].At.Before(slots[j].At) })
+	return slots
+}
+
+// FormatScheduleSlot renders a slot for the dashboard: "18:00 ExitNode → WG-SG"
+func FormatScheduleSlot(slot ScheduleSlot) string {
+	when := slot.At.Format("15:04")
+	if now := time.Now().In(vietnamTZ); slot.At.YearDay() != now.YearDay() || slot.At.Year() != now.Year() {
+		when = slot.At.Format("15:04 02/01")
+	}
+	line := fmt.Sprintf("%s %s → %s", when, slot.Group, slot.Node)
+	if !slot.SuspendedUntil.IsZero() {
+		line += " (tạm dừng do chuyển tay)"
+	}
+	return line
+}
+
+// StartNodeSchedule switches each scheduled group when one of its slots fires, checking every
+// minute. Between slots manual and failover switches are left alone, unless
+// NODE_SCHEDULE_MANUAL_SUSPEND=false, in which case a manual switch is reverted at the next
+// check. It does nothing when NODE_SCHEDULE is empty.
+func StartNodeSchedule() {
+	if len(NodeSchedule) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := applyNodeSchedule(ctx); err != nil {
+				log.Printf("⚠️  Node schedule check failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// applyNodeSchedule runs one scheduler round
+func applyNodeSchedule(ctx context.Context) error {
+	client, err := NewHTTPClient(max(5*time.Second, SingboxDelayTimeout+time.Second), SingboxTLS)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().In(vietnamTZ)
+	for _, name := range scheduleGroups() {
+		entry, at, ok := activeEntry(name, now)
+		if !ok {
+			continue
+		}
+		fired, revert := takeScheduleEvent(name, at)
+		if !fired && !revert {
+			continue
+		}
+		// Drop a hold that ended with this slot
+		suspendedUntil(name, now)
+
+		// The failover watchdog owns its group while pinned
+		if name == FailoverGroup && GetFailoverStatus().Pinned {
+			log.Printf("⏰ Node schedule %s: skipped, failover is pinned", name)
+			continue
+		}
+
+		// A bad group must not keep the groups after it from being applied
+		group, err := getProxyGroup(ctx, client, name)
+		if err != nil {
+			log.Printf("⚠️  Node schedule %s: %v", name, err)
+			rearmScheduleEvent(name, fired, revert)
+			continue
+		}
+		if !group.Switchable() {
+			log.Printf("⚠️  Node schedule: %s is a %s group and cannot be switched", name, group.Type)
+			continue
+		}
+		if group.Now == entry.Node {
+			continue
+		}
+		if !containsExact(group.All, entry.Node) {
+			log.Printf("⚠️  Node schedule: %s is not a member of %s", entry.Node, name)
+			continue
+		}
+		// Never put the watchdog's group back on a node it would leave again
+		if name == FailoverGroup && FailoverEnabled {
+			if _, err := NodeDelay(ctx, client, entry.Node); err != nil {
+				log.Printf("⚠️  Node schedule %s: keeping %s, %s is failing: %v", name, group.Now, entry.Node, err)
+				Notify(fmt.Sprintf("⏰ Lịch chuyển node %s: bỏ qua %s vì node đang lỗi (%v), giữ %s", name, entry.Node, err, group.Now))
+				continue
+			}
+		}
+
+		if err := switchNode(name, entry.Node); err != nil {
+			log.Printf("⚠️  Node schedule %s: %v", name, err)
+			rearmScheduleEvent(name, fired, revert)
+			continue
+		}
+		if err := CloseAfterSwitch(ctx); err != nil {
+			log.Printf("⚠️  Node schedule %s: %v", name, err)
+		}
+		log.Printf("⏰ Node schedule %s: %s -> %s (%s)", name, group.Now, entry.Node, entry.Spec)
+		Notify(fmt.Sprintf("⏰ Lịch chuyển node %s: %s → %s", name, group.Now, entry.Node))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// at builds a UTC minute for the schedule tests
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("parseCron(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string // empty: no match within a year
+	}{
+		{"*/15 * * * *", "2024-06-01 10:07", "2024-06-01 10:15"},
+		{"30 10 * * *", "2024-06-01 10:30", "2024-06-02 10:30"}, // strictly after
+		{"5/20 * * * *", "2024-06-01 10:06", "2024-06-01 10:25"},
+		{"5/20 * * * *", "2024-06-01 10:45", "2024-06-01 11:05"},
+		{"0 9 * * 7", "2024-06-01 12:00", "2024-06-02 09:00"}, // Sunday as 7
+		{"0 9 * * 0", "2024-06-01 12:00", "2024-06-02 09:00"},
+		{"0 0 * * 1-5", "2024-06-07 12:00", "2024-06-10 00:00"},
+		{"0 0 13 * *", "2024-06-01 00:00", "2024-06-13 00:00"},
+		{"0 0 13 * 5", "2024-06-01 00:00", "2024-06-07 00:00"}, // the 13th or any Friday
+		{"0 0 13 * 5", "2024-06-07 00:00", "2024-06-13 00:00"},
+		{"0 8,20 * 7 *", "2024-06-30 21:00", "2024-07-01 08:00"},
+		{"0 0 31 2 *", "2024-06-01 00:00", ""},
+	}
+	for _, tt := range tests {
+		expr, err := parseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.spec, err)
+		}
+		got := expr.next(at(tt.from))
+		if tt.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%q next(%s) = %s, want none", tt.spec, tt.from, got)
+			}
+			continue
+		}
+		if !got.Equal(at(tt.want)) {
+			t.Errorf("%q next(%s) = %s, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronPrev(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string // empty: no match within the lookback
+	}{
+		{"0 18 * * *", "2024-06-02 17:59", "2024-06-01 18:00"},
+		{"0 18 * * *", "2024-06-02 18:00", "2024-06-02 18:00"}, // at or before
+		{"5/20 * * * *", "2024-06-01 10:04", "2024-06-01 09:45"},
+		{"0 9 * * 7", "2024-06-05 12:00", "2024-06-02 09:00"},
+		{"0 0 13 * 5", "2024-06-12 12:00", "2024-06-07 00:00"},
+		{"0 0 13 * *", "2024-06-12 12:00", ""},
+		{"0 0 1 1 *", "2024-06-01 00:00", ""},
+	}
+	for _, tt := range tests {
+		expr, err := parseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tt.spec, err)
+		}
+		got := expr.prev(at(tt.from), scheduleLookback)
+		if tt.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%q prev(%s) = %s, want none", tt.spec, tt.from, got)
+			}
+			continue
+		}
+		if !got.Equal(at(tt.want)) {
+			t.Errorf("%q prev(%s) = %s, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestTakeScheduleEvent(t *testing.T) {
+	const group = "test-group"
+	check := func(step string, slot time.Time, wantFired, wantRevert bool) {
+		t.Helper()
+		fired, revert := takeScheduleEvent(group, slot)
+		if fired != wantFired || revert != wantRevert {
+			t.Errorf("%s: got fired=%v revert=%v, want fired=%v revert=%v", step, fired, revert, wantFired, wantRevert)
+		}
+	}
+
+	first, second := at("2024-06-01 18:00"), at("2024-06-02 01:00")
+	check("startup only records the slot in effect", first, false, false)
+	check("same slot again", first, false, false)
+	check("new slot", second, true, false)
+	check("after applying", second, false, false)
+
+	rearmScheduleEvent(group, true, false)
+	check("failed slot is retried", second, true, false)
+
+	schedule.Lock()
+	schedule.revert[group] = true
+	schedule.Unlock()
+	check("pending revert", second, false, true)
+	check("revert is taken once", second, false, false)
+}
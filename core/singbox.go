@@ -4,9 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,106 +20,413 @@ import (
 func GetSingboxInfo(ctx context.Context, resultChan chan<- SingboxInfo) {
 	defer close(resultChan)
 
-	client := &http.Client{Timeout: 3 * time.Second}
-
-	// Get proxies
-	req, err := http.NewRequestWithContext(ctx, "GET", SingboxAPI+"/proxies", nil)
+	// The dashboard waits dashboardTimeout for every source, so its delay test is shortened to fit
+	delayTimeout := min(SingboxDelayTimeout, dashboardTimeout-2*time.Second)
+	client, err := NewHTTPClient(max(3*time.Second, delayTimeout+time.Second), SingboxTLS)
 	if err != nil {
 		resultChan <- SingboxInfo{Error: err.Error()}
 		return
 	}
 
-	resp, err := client.Do(req)
+	groups, err := listProxyGroups(ctx, client)
 	if err != nil {
 		resultChan <- SingboxInfo{Error: err.Error()}
 		return
 	}
+
+	info := SingboxInfo{Groups: groups}
+
+	// Get delays for every group and the traffic counters concurrently
+	var wg sync.WaitGroup
+	for i := range groups {
+		wg.Add(1)
+		go func(g *ProxyGroup) {
+			defer wg.Done()
+			g.Delays = groupDelays(ctx, client, g.Name, g.All, delayTimeout)
+		}(&groups[i])
+	}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if mode, err := GetClashMode(ctx); err == nil {
+			info.Mode = mode
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if up, down, err := getTraffic(ctx); err == nil {
+			info.UpRate, info.DownRate = up, down
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if snap, err := GetConnections(ctx); err == nil {
+			info.UploadTotal, info.DownloadTotal = snap.UploadTotal, snap.DownloadTotal
+			info.Connections = len(snap.Connections)
+		}
+	}()
+	wg.Wait()
+
+	info.CurrentNode = groups[0].Now
+	info.AllNodes = groups[0].All
+	info.NodeDelays = groups[0].Delays
+	info.Schedule = UpcomingSchedule()
+	resultChan <- info
+}
+
+// Special NodeDelays values for nodes without a measured delay
+const (
+	DelayTimeout = -1 // The test did not finish within SINGBOX_DELAY_TIMEOUT
+	DelayError   = -2 // The test request itself failed
+)
+
+// ErrDelayTimeout is returned by NodeDelay when the node did not answer in time
+var ErrDelayTimeout = errors.New("delay test timed out")
+
+// FormatDelay renders a NodeDelays value: "123ms", "timeout", "lỗi" or "N/A" (not tested)
+func FormatDelay(delay int) string {
+	switch {
+	case delay > 0:
+		return fmt.Sprintf("%dms", delay)
+	case delay == DelayTimeout:
+		return "timeout"
+	case delay == DelayError:
+		return "lỗi"
+	}
+	return "N/A"
+}
+
+// delayQuery is the query string of the Clash API delay endpoints
+func delayQuery(timeout time.Duration) string {
+	return url.Values{
+		"url":     {SingboxDelayURL},
+		"timeout": {strconv.FormatInt(timeout.Milliseconds(), 10)},
+	}.Encode()
+}
+
+// listProxyGroups returns the exposed groups: SINGBOX_GROUPS in its order,
+// otherwise every selector then urltest group by name
+func listProxyGroups(ctx context.Context, client *http.Client) ([]ProxyGroup, error) {
+	resp, err := singboxDo(ctx, client, "GET", "/proxies", nil)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
 	var proxiesResp struct {
 		Proxies map[string]struct {
-			Now string   `json:"now"`
-			All []string `json:"all"`
+			Type string   `json:"type"`
+			Now  string   `json:"now"`
+			All  []string `json:"all"`
 		} `json:"proxies"`
 	}
+	if err := json.NewDecoder(resp.Body).Decode(&proxiesResp); err != nil {
+		return nil, err
+	}
 
-	if err := json.Unmarshal(body, &proxiesResp); err != nil {
-		resultChan <- SingboxInfo{Error: err.Error()}
-		return
+	names := SingboxGroups
+	if len(names) == 0 {
+		for name, proxy := range proxiesResp.Proxies {
+			if isProxyGroup(proxy.Type) && name != "GLOBAL" {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			ti, tj := proxiesResp.Proxies[names[i]].Type, proxiesResp.Proxies[names[j]].Type
+			if ti != tj {
+				return ti == "Selector"
+			}
+			return names[i] < names[j]
+		})
 	}
 
-	exitNode, ok := proxiesResp.Proxies["ExitNode"]
-	if !ok {
-		resultChan <- SingboxInfo{Error: "ExitNode not found"}
-		return
+	var groups []ProxyGroup
+	for _, name := range names {
+		proxy, ok := proxiesResp.Proxies[name]
+		if !ok || !isProxyGroup(proxy.Type) {
+			continue
+		}
+		groups = append(groups, newProxyGroup(name, proxy.Type, proxy.Now, proxy.All))
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("no selector group found")
+	}
+	return groups, nil
+}
+
+// newProxyGroup builds a group, dropping the members listed in SINGBOX_HIDE_NODES
+func newProxyGroup(name, proxyType, now string, all []string) ProxyGroup {
+	group := ProxyGroup{Name: name, Type: proxyType, Now: now}
+	for _, node := range all {
+		if !contains(SingboxHiddenNodes, node) {
+			group.All = append(group.All, node)
+		}
+	}
+	return group
+}
+
+// missingProbeTimeout bounds the second look at nodes missing from a group test: a node that
+// fails outright answers at once, one that times out again is cut off here
+const missingProbeTimeout = time.Second
+
+// groupDelays runs the group delay test. The API only returns the nodes that answered, so each
+// missing node is tested on its own to tell a timeout (DelayTimeout) from a failure (DelayError).
+func groupDelays(ctx context.Context, client *http.Client, group string, nodes []string, timeout time.Duration) map[string]int {
+	answered := make(map[string]int)
+	resp, err := singboxDo(ctx, client, "GET", "/group/"+url.PathEscape(group)+"/delay?"+delayQuery(timeout), nil)
+	if err == nil {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		// A bad body leaves every node to the probes below
+		json.Unmarshal(body, &answered)
 	}
 
-	currentNode := exitNode.Now
-	allNodes := make([]string, 0)
-	for _, node := range exitNode.All {
-		if node != "REJECT" && node != "GLOBAL" {
-			allNodes = append(allNodes, node)
+	probeCtx, cancel := context.WithTimeout(ctx, min(timeout, missingProbeTimeout))
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	nodeDelays := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		if d := answered[node]; d > 0 {
+			nodeDelays[node] = d
+			continue
 		}
+		wg.Add(1)
+		go func(node string) {
+			defer wg.Done()
+			d, err := nodeDelay(probeCtx, client, node, timeout)
+			switch {
+			case err == nil:
+			case errors.Is(err, ErrDelayTimeout) || probeCtx.Err() != nil:
+				d = DelayTimeout
+			default:
+				d = DelayError
+			}
+			mu.Lock()
+			nodeDelays[node] = d
+			mu.Unlock()
+		}(node)
 	}
+	wg.Wait()
+	return nodeDelays
+}
 
-	// Get delays
-	delayURL := fmt.Sprintf("%s/group/ExitNode/delay?url=https%%3A%%2F%%2Fdns.google%%2F&timeout=2000", SingboxAPI)
-	req2, err := http.NewRequestWithContext(ctx, "GET", delayURL, nil)
+// NodeDelay tests one outbound through /proxies/{name}/delay and returns the delay in ms.
+// A node that does not answer in time yields ErrDelayTimeout.
+func NodeDelay(ctx context.Context, client *http.Client, node string) (int, error) {
+	return nodeDelay(ctx, client, node, SingboxDelayTimeout)
+}
+
+// nodeDelay is NodeDelay with an explicit test timeout
+func nodeDelay(ctx context.Context, client *http.Client, node string, timeout time.Duration) (int, error) {
+	resp, err := singboxDo(ctx, client, "GET", "/proxies/"+url.PathEscape(node)+"/delay?"+delayQuery(timeout), nil)
 	if err != nil {
-		resultChan <- SingboxInfo{
-			CurrentNode: currentNode,
-			AllNodes:    allNodes,
-			NodeDelays:  make(map[string]int),
+		// sing-box answers 504 when the test URL was not reached within the timeout
+		var statusErr *singboxStatusError
+		if errors.As(err, &statusErr) && statusErr.Status == http.StatusGatewayTimeout {
+			return 0, ErrDelayTimeout
 		}
-		return
+		return 0, err
 	}
+	defer resp.Body.Close()
 
-	resp2, err := client.Do(req2)
-	nodeDelays := make(map[string]int)
-	if err == nil {
-		defer resp2.Body.Close()
-		body2, _ := io.ReadAll(resp2.Body)
-		json.Unmarshal(body2, &nodeDelays)
+	var result struct {
+		Delay int `json:"delay"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	if result.Delay <= 0 {
+		return 0, ErrDelayTimeout
+	}
+	return result.Delay, nil
+}
+
+// getProxyGroup reads one group from /proxies/{name} without running delay tests
+func getProxyGroup(ctx context.Context, client *http.Client, name string) (ProxyGroup, error) {
+	resp, err := singboxDo(ctx, client, "GET", "/proxies/"+url.PathEscape(name), nil)
+	if err != nil {
+		return ProxyGroup{}, err
+	}
+	defer resp.Body.Close()
+
+	var proxy struct {
+		Type string   `json:"type"`
+		Now  string   `json:"now"`
+		All  []string `json:"all"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&proxy); err != nil {
+		return ProxyGroup{}, err
+	}
+	if !isProxyGroup(proxy.Type) {
+		return ProxyGroup{}, fmt.Errorf("%s is a %s outbound, not a group", name, proxy.Type)
 	}
 
-	// Set 0 for nodes without delay
-	for _, node := range allNodes {
-		if _, exists := nodeDelays[node]; !exists {
-			nodeDelays[node] = 0
+	return newProxyGroup(name, proxy.Type, proxy.Now, proxy.All), nil
+}
+
+// FetchSingboxInfo is a synchronous wrapper around GetSingboxInfo
+func FetchSingboxInfo(ctx context.Context) SingboxInfo {
+	ch := make(chan SingboxInfo, 1)
+	go GetSingboxInfo(ctx, ch)
+	return <-ch
+}
+
+// ListProxyGroups reads the exposed groups in dashboard order without running delay tests
+func ListProxyGroups(ctx context.Context) ([]ProxyGroup, error) {
+	client, err := NewHTTPClient(5*time.Second, SingboxTLS)
+	if err != nil {
+		return nil, err
+	}
+	return listProxyGroups(ctx, client)
+}
+
+// FindGroup returns the exposed group with the given name
+func (s SingboxInfo) FindGroup(name string) (ProxyGroup, bool) {
+	return FindProxyGroup(s.Groups, name)
+}
+
+// FindProxyGroup returns the group with the given name
+func FindProxyGroup(groups []ProxyGroup, name string) (ProxyGroup, bool) {
+	for _, g := range groups {
+		if g.Name == name {
+			return g, true
 		}
 	}
+	return ProxyGroup{}, false
+}
+
+// isProxyGroup reports whether a Clash API proxy type is a group the bot can show
+func isProxyGroup(proxyType string) bool {
+	return proxyType == "Selector" || proxyType == "URLTest"
+}
+
+// ClashModes are the routing modes offered in chat
+var ClashModes = []string{"rule", "global", "direct"}
 
-	resultChan <- SingboxInfo{
-		CurrentNode: currentNode,
-		AllNodes:    allNodes,
-		NodeDelays:  nodeDelays,
+// GetClashMode reads the current routing mode from /configs
+func GetClashMode(ctx context.Context) (string, error) {
+	client, err := NewHTTPClient(3*time.Second, SingboxTLS)
+	if err != nil {
+		return "", err
+	}
+	resp, err := singboxDo(ctx, client, "GET", "/configs", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var configs struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&configs); err != nil {
+		return "", err
 	}
+	return strings.ToLower(configs.Mode), nil
 }
 
-// SwitchNode switches to a different VPN exit node
-func SwitchNode(nodeName string) error {
-	client := &http.Client{Timeout: 5 * time.Second}
+// SetClashMode switches the routing mode (rule, global or direct) through PATCH /configs
+func SetClashMode(ctx context.Context, mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if !contains(ClashModes, mode) {
+		return fmt.Errorf("unknown mode %q (use %s)", mode, strings.Join(ClashModes, ", "))
+	}
 
-	reqBody := fmt.Sprintf(`{"name":"%s"}`, nodeName)
-	req, err := http.NewRequest("PUT", SingboxAPI+"/proxies/ExitNode",
-		io.NopCloser(io.Reader(nil)))
+	client, err := NewHTTPClient(5*time.Second, SingboxTLS)
 	if err != nil {
 		return err
 	}
+	reqBody, _ := json.Marshal(map[string]string{"mode": mode})
+	resp, err := singboxDo(ctx, client, "PATCH", "/configs", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to set mode: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Body = io.NopCloser(bytes.NewReader([]byte(reqBody)))
+// SwitchNode selects nodeName in the given selector group on behalf of a user; see switchNode.
+// The switch is then noted for the node schedule (NODE_SCHEDULE_MANUAL_SUSPEND).
+func SwitchNode(group, nodeName string) error {
+	if err := switchNode(group, nodeName); err != nil {
+		return err
+	}
+	noteManualSwitch(group)
+	return nil
+}
 
-	resp, err := client.Do(req)
+// switchNode selects nodeName in the given selector group
+func switchNode(group, nodeName string) error {
+	client, err := NewHTTPClient(5*time.Second, SingboxTLS)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 204 {
-		return fmt.Errorf("failed to switch node: status %d", resp.StatusCode)
+	reqBody, _ := json.Marshal(map[string]string{"name": nodeName})
+	resp, err := singboxDo(context.Background(), client, "PUT", "/proxies/"+url.PathEscape(group), bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to switch node: %w", err)
 	}
+	resp.Body.Close()
+	return nil
+}
 
+// CloseAfterSwitch closes every connection when SINGBOX_CLOSE_ON_SWITCH is set, since
+// long-lived connections keep the old outbound until they are closed. Callers run it after a
+// successful switch and report a failure as a warning: the switch itself already happened.
+func CloseAfterSwitch(ctx context.Context) error {
+	if !SingboxCloseOnSwitch {
+		return nil
+	}
+	if err := CloseAllConnections(ctx); err != nil {
+		return fmt.Errorf("closing connections failed: %w", err)
+	}
 	return nil
 }
+
+// ErrSingboxUnauthorized is returned when the Clash API rejects (or requires) the secret
+var ErrSingboxUnauthorized = errors.New("sing-box API returned 401 Unauthorized: check SINGBOX_SECRET against clash_api.secret")
+
+// singboxDo sends a request to the Clash API with the configured secret.
+// Non-2xx responses are turned into errors so callers only decode successful bodies.
+func singboxDo(ctx context.Context, client *http.Client, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, SingboxAPI+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if SingboxSecret != "" {
+		req.Header.Set("Authorization", "Bearer "+SingboxSecret)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, ErrSingboxUnauthorized
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		endpoint, _, _ := strings.Cut(path, "?")
+		return nil, &singboxStatusError{Method: method, Path: endpoint, Status: resp.StatusCode, Body: string(bytes.TrimSpace(msg))}
+	}
+	return resp, nil
+}
+
+// singboxStatusError is a non-2xx Clash API response
+type singboxStatusError struct {
+	Method string
+	Path   string
+	Status int
+	Body   string
+}
+
+func (e *singboxStatusError) Error() string {
+	return fmt.Sprintf("sing-box API %s %s: status %d %s", e.Method, e.Path, e.Status, e.Body)
+}
@@ -0,0 +1,531 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutboundSummary is one outbound (or endpoint) of the sing-box config file
+type OutboundSummary struct {
+	Tag     string
+	Type    string
+	Members []string // selector/urltest members
+	Detail  string   // bind_interface, server or WireGuard interface name
+}
+
+// orderedObject is a JSON object that keeps its key order when re-encoded,
+// so edited config files stay diffable against the original
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func (o *orderedObject) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return errors.New("expected a JSON object")
+	}
+	o.keys, o.values = nil, make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if _, dup := o.values[key]; !dup {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+	}
+	_, err = dec.Token()
+	return err
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// set replaces (or appends) a key with the JSON encoding of v
+func (o *orderedObject) set(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+	return nil
+}
+
+// del removes a key
+func (o *orderedObject) del(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// singboxOutbound holds the fields the bot reads from outbounds and endpoints
+type singboxOutbound struct {
+	Type          string   `json:"type"`
+	Tag           string   `json:"tag"`
+	Outbounds     []string `json:"outbounds"`
+	Default       string   `json:"default"`
+	BindInterface string   `json:"bind_interface"`
+	Server        string   `json:"server"`
+	Name          string   `json:"name"`
+}
+
+// singboxConfig is the subset of the config file the bot validates
+type singboxConfig struct {
+	Outbounds []singboxOutbound `json:"outbounds"`
+	Endpoints []singboxOutbound `json:"endpoints"`
+	Route     struct {
+		Final string `json:"final"`
+		Rules []struct {
+			Outbound string `json:"outbound"`
+		} `json:"rules"`
+	} `json:"route"`
+}
+
+// ListOutbounds reads the outbounds and endpoints from SINGBOX_CONFIG_FILE
+func ListOutbounds() ([]OutboundSummary, error) {
+	raw, err := readSingboxConfig()
+	if err != nil {
+		return nil, err
+	}
+	var cfg singboxConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+
+	var out []OutboundSummary
+	for _, o := range append(cfg.Outbounds, cfg.Endpoints...) {
+		detail := o.BindInterface
+		if detail == "" {
+			detail = o.Server
+		}
+		if detail == "" {
+			detail = o.Name
+		}
+		out = append(out, OutboundSummary{Tag: o.Tag, Type: o.Type, Members: o.Outbounds, Detail: detail})
+	}
+	return out, nil
+}
+
+// FormatOutboundsTable renders outbounds for a code block, selectors with their members
+func FormatOutboundsTable(outbounds []OutboundSummary) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-20s %-10s %s\n", "TAG", "TYPE", "DETAIL"))
+	for _, o := range outbounds {
+		detail := o.Detail
+		if len(o.Members) > 0 {
+			detail = strings.Join(o.Members, ", ")
+		}
+		sb.WriteString(fmt.Sprintf("%-20s %-10s %s\n", clip(o.Tag, 20), o.Type, detail))
+	}
+	return sb.String()
+}
+
+// ValidateSingboxConfig checks the structure and tag references of a config file
+func ValidateSingboxConfig(raw []byte) error {
+	var top orderedObject
+	if err := json.Unmarshal(raw, &top); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	var cfg singboxConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("invalid structure: %w", err)
+	}
+	if len(cfg.Outbounds) == 0 {
+		return errors.New("no outbounds defined")
+	}
+
+	tags := make(map[string]bool)
+	for i, o := range append(cfg.Outbounds, cfg.Endpoints...) {
+		if o.Type == "" {
+			return fmt.Errorf("outbound #%d has no type", i+1)
+		}
+		if o.Tag == "" {
+			return fmt.Errorf("outbound #%d (%s) has no tag", i+1, o.Type)
+		}
+		if tags[o.Tag] {
+			return fmt.Errorf("duplicate tag %q", o.Tag)
+		}
+		tags[o.Tag] = true
+	}
+
+	for _, o := range cfg.Outbounds {
+		if o.Type != "selector" && o.Type != "urltest" {
+			continue
+		}
+		if len(o.Outbounds) == 0 {
+			return fmt.Errorf("%s %q has no outbounds", o.Type, o.Tag)
+		}
+		for _, member := range o.Outbounds {
+			if !tags[member] {
+				return fmt.Errorf("%s %q references unknown outbound %q", o.Type, o.Tag, member)
+			}
+		}
+		if o.Default != "" && !containsExact(o.Outbounds, o.Default) {
+			return fmt.Errorf("selector %q default %q is not one of its outbounds", o.Tag, o.Default)
+		}
+	}
+
+	if cfg.Route.Final != "" && !tags[cfg.Route.Final] {
+		return fmt.Errorf("route.final references unknown outbound %q", cfg.Route.Final)
+	}
+	for i, rule := range cfg.Route.Rules {
+		if rule.Outbound != "" && !tags[rule.Outbound] {
+			return fmt.Errorf("route rule #%d references unknown outbound %q", i+1, rule.Outbound)
+		}
+	}
+	return nil
+}
+
+// containsExact reports whether list contains s (tags are case-sensitive)
+func containsExact(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AddToSelector adds tag to a selector's outbounds. When definition (a JSON outbound object)
+// is given, it is appended to the outbounds list first; its tag must equal tag.
+func AddToSelector(ctx context.Context, selector, tag, definition string, progress func(string)) error {
+	return editSelector(ctx, selector, progress, func(outbounds []json.RawMessage, sel *orderedObject, members []string) ([]json.RawMessage, []string, error) {
+		if definition != "" {
+			var def orderedObject
+			if err := json.Unmarshal([]byte(definition), &def); err != nil {
+				return nil, nil, fmt.Errorf("outbound definition: %w", err)
+			}
+			var defTag string
+			json.Unmarshal(def.values["tag"], &defTag)
+			if defTag != tag {
+				return nil, nil, fmt.Errorf("outbound definition has tag %q, expected %q", defTag, tag)
+			}
+			raw, _ := json.Marshal(def)
+			outbounds = append(outbounds, raw)
+		}
+		if containsExact(members, tag) {
+			return nil, nil, fmt.Errorf("%q is already in %s", tag, selector)
+		}
+		return outbounds, append(members, tag), nil
+	})
+}
+
+// RemoveFromSelector removes tag from a selector's outbounds (the outbound itself is kept)
+func RemoveFromSelector(ctx context.Context, selector, tag string, progress func(string)) error {
+	return editSelector(ctx, selector, progress, func(outbounds []json.RawMessage, sel *orderedObject, members []string) ([]json.RawMessage, []string, error) {
+		var kept []string
+		for _, m := range members {
+			if m != tag {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) == len(members) {
+			return nil, nil, fmt.Errorf("%q is not in %s", tag, selector)
+		}
+		var def string
+		json.Unmarshal(sel.values["default"], &def)
+		if def == tag {
+			sel.del("default")
+		}
+		return outbounds, kept, nil
+	})
+}
+
+// selectorEdit changes the outbounds list and the selector's members
+type selectorEdit func(outbounds []json.RawMessage, sel *orderedObject, members []string) ([]json.RawMessage, []string, error)
+
+// singboxConfigMu serialises config edits: the read-modify-write-reload-rollback sequence must
+// not interleave, or one edit could overwrite (or roll back over) another
+var singboxConfigMu sync.Mutex
+
+// editSelector loads the config, applies edit to the named selector and applies the result
+func editSelector(ctx context.Context, selector string, progress func(string), edit selectorEdit) error {
+	singboxConfigMu.Lock()
+	defer singboxConfigMu.Unlock()
+
+	raw, err := readSingboxConfig()
+	if err != nil {
+		return err
+	}
+
+	var top orderedObject
+	if err := json.Unmarshal(raw, &top); err != nil {
+		return err
+	}
+	var outbounds []json.RawMessage
+	if err := json.Unmarshal(top.values["outbounds"], &outbounds); err != nil {
+		return fmt.Errorf("outbounds: %w", err)
+	}
+
+	index := -1
+	var sel orderedObject
+	for i, o := range outbounds {
+		var head singboxOutbound
+		if json.Unmarshal(o, &head) == nil && head.Tag == selector {
+			if head.Type != "selector" {
+				return fmt.Errorf("%s is a %s outbound, not a selector", selector, head.Type)
+			}
+			if err := json.Unmarshal(o, &sel); err != nil {
+				return err
+			}
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("selector %q not found", selector)
+	}
+
+	var members []string
+	json.Unmarshal(sel.values["outbounds"], &members)
+	outbounds, members, err = edit(outbounds, &sel, members)
+	if err != nil {
+		return err
+	}
+	if err := sel.set("outbounds", members); err != nil {
+		return err
+	}
+	if outbounds[index], err = json.Marshal(sel); err != nil {
+		return err
+	}
+	if err := top.set("outbounds", outbounds); err != nil {
+		return err
+	}
+
+	compact, err := json.Marshal(top)
+	if err != nil {
+		return err
+	}
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, compact, "", "    "); err != nil {
+		return err
+	}
+	return applySingboxConfig(ctx, pretty.Bytes(), progress)
+}
+
+// ApplySingboxConfig validates, backs up, writes and reloads a new config, restoring the
+// previous file when sing-box does not come back healthy within SINGBOX_RELOAD_TIMEOUT
+func ApplySingboxConfig(ctx context.Context, raw []byte, progress func(string)) error {
+	singboxConfigMu.Lock()
+	defer singboxConfigMu.Unlock()
+	return applySingboxConfig(ctx, raw, progress)
+}
+
+// applySingboxConfig is ApplySingboxConfig for callers already holding singboxConfigMu
+func applySingboxConfig(ctx context.Context, raw []byte, progress func(string)) error {
+	if err := ValidateSingboxConfig(raw); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if err := runSingboxCheck(ctx, raw); err != nil {
+		return fmt.Errorf("sing-box check failed: %w", err)
+	}
+	progress("✅ Config hợp lệ")
+
+	old, err := readSingboxConfig()
+	if err != nil {
+		return err
+	}
+	backup, err := backupSingboxConfig(old)
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	progress("💾 Đã sao lưu: " + filepath.Base(backup))
+
+	if err := writeFileAtomic(SingboxConfigFile, raw); err != nil {
+		return err
+	}
+	progress("🔄 Đang reload sing-box...")
+
+	reloadErr := runShell(ctx, SingboxReloadCmd)
+	if reloadErr == nil {
+		reloadErr = waitSingboxHealthy(ctx, SingboxReloadTimeout)
+	}
+	if reloadErr == nil {
+		progress("✅ sing-box đã chạy lại với config mới")
+		return nil
+	}
+
+	// Roll back to the previous file. The failed reload may have used up ctx, so the rollback
+	// gets its own deadline: sing-box must not be left stopped or on the bad config.
+	progress("⚠️ sing-box không khỏe (" + reloadErr.Error() + "), đang khôi phục config cũ...")
+	if err := writeFileAtomic(SingboxConfigFile, old); err != nil {
+		return fmt.Errorf("%v; rollback write failed: %w", reloadErr, err)
+	}
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), SingboxReloadTimeout+30*time.Second)
+	defer cancel()
+	if err := runShell(rollbackCtx, SingboxReloadCmd); err != nil {
+		return fmt.Errorf("%v; rollback reload failed: %w", reloadErr, err)
+	}
+	if err := waitSingboxHealthy(rollbackCtx, SingboxReloadTimeout); err != nil {
+		return fmt.Errorf("%v; still unhealthy after rollback: %w", reloadErr, err)
+	}
+	return fmt.Errorf("reload failed (%v), previous config restored", reloadErr)
+}
+
+// readSingboxConfig reads SINGBOX_CONFIG_FILE
+func readSingboxConfig() ([]byte, error) {
+	if SingboxConfigFile == "" {
+		return nil, errors.New("SINGBOX_CONFIG_FILE is not set")
+	}
+	return os.ReadFile(SingboxConfigFile)
+}
+
+// backupSingboxConfig writes <file>.<timestamp>.bak and prunes old backups beyond SINGBOX_CONFIG_BACKUPS.
+// The timestamp has nanoseconds and the file is created exclusively, so no backup is overwritten.
+func backupSingboxConfig(raw []byte) (string, error) {
+	var (
+		name string
+		f    *os.File
+		err  error
+	)
+	for attempt := 0; attempt < 3; attempt++ {
+		name = fmt.Sprintf("%s.%s.bak", SingboxConfigFile, time.Now().In(vietnamTZ).Format("20060102-150405.000000000"))
+		f, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if !errors.Is(err, os.ErrExist) {
+			break
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	backups, _ := filepath.Glob(SingboxConfigFile + ".*.bak")
+	sort.Strings(backups) // Timestamps sort chronologically
+	for len(backups) > SingboxConfigBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return name, nil
+}
+
+// writeFileAtomic replaces path through a temporary file in the same directory, keeping its mode
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// runSingboxCheck runs SINGBOX_CHECK_CMD ("{file}" is replaced by a temporary copy of raw)
+func runSingboxCheck(ctx context.Context, raw []byte) error {
+	if SingboxCheckCmd == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp("", "sing-box-check-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	return runShell(ctx, strings.ReplaceAll(SingboxCheckCmd, "{file}", tmp.Name()))
+}
+
+// runShell runs a configured command line through sh, returning its output on failure
+func runShell(ctx context.Context, command string) error {
+	out, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, clip(msg, 300))
+		}
+		return err
+	}
+	return nil
+}
+
+// waitSingboxHealthy polls the Clash API /version until it answers or timeout elapses
+func waitSingboxHealthy(ctx context.Context, timeout time.Duration) error {
+	client, err := NewHTTPClient(3*time.Second, SingboxTLS)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	// Give the old process time to exit before the first probe
+	time.Sleep(2 * time.Second)
+	for {
+		resp, err := singboxDo(ctx, client, "GET", "/version", nil)
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("controller not reachable after %s: %w", timeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("controller not reachable (%v): %w", ctx.Err(), err)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
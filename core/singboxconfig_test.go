@@ -0,0 +1,112 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOrderedObjectRoundTrip(t *testing.T) {
+	const raw = `{"route":{"final":"proxy","rules":[]},"log":{"level":"info"},"outbounds":[{"type":"direct","tag":"direct"}],"dns":null}`
+
+	var o orderedObject
+	if err := json.Unmarshal([]byte(raw), &o); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != raw {
+		t.Errorf("round trip changed the object:\n got %s\nwant %s", out, raw)
+	}
+
+	// Replacing a key keeps its place, new keys go last
+	if err := o.set("log", map[string]string{"level": "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.set("experimental", map[string]bool{"cache": true}); err != nil {
+		t.Fatal(err)
+	}
+	o.del("dns")
+	o.del("missing")
+	out, err = json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"route":{"final":"proxy","rules":[]},"log":{"level":"debug"},"outbounds":[{"type":"direct","tag":"direct"}],"experimental":{"cache":true}}`
+	if string(out) != want {
+		t.Errorf("after edits:\n got %s\nwant %s", out, want)
+	}
+}
+
+func TestOrderedObjectRejectsNonObjects(t *testing.T) {
+	for _, raw := range []string{`[]`, `"x"`, `{"a":1`} {
+		var o orderedObject
+		if err := json.Unmarshal([]byte(raw), &o); err == nil {
+			t.Errorf("Unmarshal(%s): expected an error", raw)
+		}
+	}
+}
+
+func TestValidateSingboxConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		err  string // expected error substring; empty means valid
+	}{
+		{
+			name: "valid",
+			raw: `{"outbounds":[{"type":"selector","tag":"proxy","outbounds":["sg","direct"],"default":"sg"},
+				{"type":"direct","tag":"direct"}],
+				"endpoints":[{"type":"wireguard","tag":"sg"}],
+				"route":{"final":"proxy","rules":[{"outbound":"direct"}]}}`,
+		},
+		{name: "invalid JSON", raw: `{"outbounds":`, err: "invalid JSON"},
+		{name: "bad structure", raw: `{"outbounds":{}}`, err: "invalid structure"},
+		{name: "no outbounds", raw: `{"outbounds":[]}`, err: "no outbounds"},
+		{name: "missing type", raw: `{"outbounds":[{"tag":"a"}]}`, err: "has no type"},
+		{name: "missing tag", raw: `{"outbounds":[{"type":"direct"}]}`, err: "has no tag"},
+		{
+			name: "duplicate tag across endpoints",
+			raw:  `{"outbounds":[{"type":"direct","tag":"a"}],"endpoints":[{"type":"wireguard","tag":"a"}]}`,
+			err:  `duplicate tag "a"`,
+		},
+		{
+			name: "empty selector",
+			raw:  `{"outbounds":[{"type":"selector","tag":"proxy"}]}`,
+			err:  `selector "proxy" has no outbounds`,
+		},
+		{
+			name: "unknown member",
+			raw:  `{"outbounds":[{"type":"urltest","tag":"auto","outbounds":["gone"]}]}`,
+			err:  `references unknown outbound "gone"`,
+		},
+		{
+			name: "default outside members",
+			raw:  `{"outbounds":[{"type":"selector","tag":"proxy","outbounds":["a"],"default":"b"},{"type":"direct","tag":"a"},{"type":"direct","tag":"b"}]}`,
+			err:  `default "b" is not one of its outbounds`,
+		},
+		{
+			name: "unknown final",
+			raw:  `{"outbounds":[{"type":"direct","tag":"a"}],"route":{"final":"b"}}`,
+			err:  `route.final references unknown outbound "b"`,
+		},
+		{
+			name: "unknown rule outbound",
+			raw:  `{"outbounds":[{"type":"direct","tag":"a"}],"route":{"rules":[{"outbound":"a"},{"outbound":"b"}]}}`,
+			err:  `route rule #2 references unknown outbound "b"`,
+		},
+	}
+	for _, tt := range tests {
+		err := ValidateSingboxConfig([]byte(tt.raw))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("%s: expected an error containing %q", tt.name, tt.err)
+		case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+			t.Errorf("%s: error %q does not contain %q", tt.name, err, tt.err)
+		}
+	}
+}
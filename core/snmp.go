@@ -0,0 +1,165 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// SNMPTarget holds connection settings for one SNMP agent
+type SNMPTarget struct {
+	Host      string
+	Port      uint16
+	Version   string // "2c" or "3"
+	Community string // v2c only
+	Timeout   time.Duration
+	Retries   int
+
+	// SNMPv3 USM settings
+	User      string
+	AuthProto string // MD5, SHA, SHA224, SHA256, SHA384, SHA512
+	AuthPass  string
+	PrivProto string // DES, AES, AES192, AES256, AES192C, AES256C
+	PrivPass  string
+}
+
+// LoadSNMPTarget reads <PREFIX>_SNMP_* variables for an agent at host.
+// community is the v2c default used when <PREFIX>_SNMP_COMMUNITY is unset.
+func LoadSNMPTarget(prefix, host, community string) SNMPTarget {
+	env := func(key string) string { return os.Getenv(prefix + "_SNMP_" + key) }
+
+	t := SNMPTarget{
+		Host:      host,
+		Port:      161,
+		Version:   strings.TrimPrefix(strings.ToLower(env("VERSION")), "v"),
+		Community: community,
+		Timeout:   parseDuration(env("TIMEOUT"), 5*time.Second),
+		Retries:   3,
+		User:      env("USER"),
+		AuthProto: strings.ToUpper(env("AUTH_PROTO")),
+		AuthPass:  env("AUTH_PASS"),
+		PrivProto: strings.ToUpper(env("PRIV_PROTO")),
+		PrivPass:  env("PRIV_PASS"),
+	}
+	if c := env("COMMUNITY"); c != "" {
+		t.Community = c
+	}
+	if p, err := strconv.ParseUint(env("PORT"), 10, 16); err == nil && p > 0 {
+		t.Port = uint16(p)
+	}
+	if r, err := strconv.Atoi(env("RETRIES")); err == nil && r >= 0 {
+		t.Retries = r
+	}
+	if t.Version == "" {
+		t.Version = "2c"
+	}
+	if t.Version == "3" && t.AuthProto == "" {
+		t.AuthProto = "SHA"
+	}
+	if t.Version == "3" && t.PrivProto == "" {
+		t.PrivProto = "AES"
+	}
+	return t
+}
+
+// NewSNMPClient builds and connects a gosnmp client for target.
+// Callers own the returned client and must close snmp.Conn.
+func NewSNMPClient(target SNMPTarget) (*gosnmp.GoSNMP, error) {
+	snmp := &gosnmp.GoSNMP{
+		Target:  target.Host,
+		Port:    target.Port,
+		Timeout: target.Timeout,
+		Retries: target.Retries,
+		MaxOids: gosnmp.MaxOids,
+	}
+
+	switch target.Version {
+	case "2c":
+		snmp.Version = gosnmp.Version2c
+		snmp.Community = target.Community
+
+	case "3":
+		flags, params, err := usmParams(target)
+		if err != nil {
+			return nil, err
+		}
+		snmp.Version = gosnmp.Version3
+		snmp.SecurityModel = gosnmp.UserSecurityModel
+		snmp.MsgFlags = flags
+		snmp.SecurityParameters = params
+
+	default:
+		return nil, fmt.Errorf("unsupported SNMP version %q", target.Version)
+	}
+
+	if err := snmp.Connect(); err != nil {
+		return nil, err
+	}
+	return snmp, nil
+}
+
+// usmParams builds USM security parameters; the security level follows from which passphrases are set
+func usmParams(target SNMPTarget) (gosnmp.SnmpV3MsgFlags, *gosnmp.UsmSecurityParameters, error) {
+	auth, err := snmpAuthProtocol(target.AuthProto)
+	if err != nil {
+		return 0, nil, err
+	}
+	priv, err := snmpPrivProtocol(target.PrivProto)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	flags := gosnmp.NoAuthNoPriv
+	params := &gosnmp.UsmSecurityParameters{UserName: target.User}
+	if target.AuthPass != "" {
+		flags = gosnmp.AuthNoPriv
+		params.AuthenticationProtocol = auth
+		params.AuthenticationPassphrase = target.AuthPass
+		if target.PrivPass != "" {
+			flags = gosnmp.AuthPriv
+			params.PrivacyProtocol = priv
+			params.PrivacyPassphrase = target.PrivPass
+		}
+	}
+	return flags, params, nil
+}
+
+func snmpAuthProtocol(name string) (gosnmp.SnmpV3AuthProtocol, error) {
+	switch name {
+	case "MD5":
+		return gosnmp.MD5, nil
+	case "SHA", "SHA1":
+		return gosnmp.SHA, nil
+	case "SHA224":
+		return gosnmp.SHA224, nil
+	case "SHA256":
+		return gosnmp.SHA256, nil
+	case "SHA384":
+		return gosnmp.SHA384, nil
+	case "SHA512":
+		return gosnmp.SHA512, nil
+	}
+	return gosnmp.NoAuth, fmt.Errorf("unsupported SNMPv3 auth protocol %q", name)
+}
+
+func snmpPrivProtocol(name string) (gosnmp.SnmpV3PrivProtocol, error) {
+	switch name {
+	case "DES":
+		return gosnmp.DES, nil
+	case "AES", "AES128":
+		return gosnmp.AES, nil
+	case "AES192":
+		return gosnmp.AES192, nil
+	case "AES256":
+		return gosnmp.AES256, nil
+	case "AES192C":
+		return gosnmp.AES192C, nil
+	case "AES256C":
+		return gosnmp.AES256C, nil
+	}
+	return gosnmp.NoPriv, fmt.Errorf("unsupported SNMPv3 privacy protocol %q", name)
+}
@@ -0,0 +1,285 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// SNMPDeviceConfig is one device entry in SNMP_DEVICES_FILE
+type SNMPDeviceConfig struct {
+	Name      string `json:"name"`
+	Host      string `json:"host"`
+	Port      uint16 `json:"port"`
+	Version   string `json:"version"` // "2c" (default) or "3"
+	Community string `json:"community"`
+	Timeout   string `json:"timeout"` // Go duration, default 2s (keeps the dashboard responsive)
+	Retries   *int   `json:"retries"`
+
+	User      string `json:"user"`
+	AuthProto string `json:"auth_proto"`
+	AuthPass  string `json:"auth_pass"`
+	PrivProto string `json:"priv_proto"`
+	PrivPass  string `json:"priv_pass"`
+
+	Metrics []SNMPMetricConfig `json:"metrics"`
+}
+
+// SNMPMetricConfig describes one value to read from a device
+type SNMPMetricConfig struct {
+	Name  string            `json:"name"`
+	OID   string            `json:"oid"`
+	Walk  bool              `json:"walk"`  // Walk the subtree and show one value per index
+	Type  string            `json:"type"`  // "number" (default), "string", "timeticks" or "enum"
+	Scale float64           `json:"scale"` // Multiplier for numbers, e.g. 0.1 for tenths
+	Unit  string            `json:"unit"`
+	Enum  map[string]string `json:"enum"` // Raw value → label for "enum"
+}
+
+// LoadSNMPDevices reads the device list from a JSON file
+func LoadSNMPDevices(path string) ([]SNMPDeviceConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var devices []SNMPDeviceConfig
+	if err := json.Unmarshal(raw, &devices); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, d := range devices {
+		if d.Name == "" || d.Host == "" {
+			return nil, fmt.Errorf("%s: device #%d needs a name and host", path, i+1)
+		}
+		for _, m := range d.Metrics {
+			if m.Name == "" || m.OID == "" {
+				return nil, fmt.Errorf("%s: device %q has a metric without name or oid", path, d.Name)
+			}
+		}
+	}
+	return devices, nil
+}
+
+// Target converts the device's connection settings for NewSNMPClient
+func (d SNMPDeviceConfig) Target() SNMPTarget {
+	t := SNMPTarget{
+		Host:      d.Host,
+		Port:      d.Port,
+		Version:   strings.TrimPrefix(strings.ToLower(d.Version), "v"),
+		Community: d.Community,
+		Timeout:   parseDuration(d.Timeout, 2*time.Second),
+		Retries:   1,
+		User:      d.User,
+		AuthProto: strings.ToUpper(d.AuthProto),
+		AuthPass:  d.AuthPass,
+		PrivProto: strings.ToUpper(d.PrivProto),
+		PrivPass:  d.PrivPass,
+	}
+	if t.Port == 0 {
+		t.Port = 161
+	}
+	if t.Version == "" {
+		t.Version = "2c"
+	}
+	if d.Retries != nil {
+		t.Retries = *d.Retries
+	}
+	if t.AuthProto == "" {
+		t.AuthProto = "SHA"
+	}
+	if t.PrivProto == "" {
+		t.PrivProto = "AES"
+	}
+	return t
+}
+
+// snmpDevicesTimeout bounds the device polls so a slow or unreachable device cannot use up
+// the dashboard's budget and cost every other section
+const snmpDevicesTimeout = dashboardTimeout - time.Second
+
+// GetSNMPDevices polls every configured generic device concurrently. A device that has not
+// answered within snmpDevicesTimeout is reported with an error instead of holding the result.
+func GetSNMPDevices(ctx context.Context, resultChan chan<- []DeviceInfo) {
+	defer close(resultChan)
+
+	if len(SNMPDevices) == 0 {
+		resultChan <- nil
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, snmpDevicesTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	results := make([]DeviceInfo, len(SNMPDevices))
+	done := make([]bool, len(SNMPDevices))
+	var wg sync.WaitGroup
+	for i, device := range SNMPDevices {
+		wg.Add(1)
+		go func(i int, device SNMPDeviceConfig) {
+			defer wg.Done()
+			info := pollSNMPDevice(ctx, device)
+			mu.Lock()
+			results[i], done[i] = info, true
+			mu.Unlock()
+		}(i, device)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]DeviceInfo, len(results))
+	for i, info := range results {
+		if !done[i] {
+			info = DeviceInfo{Name: SNMPDevices[i].Name, Error: fmt.Sprintf("no answer within %s", snmpDevicesTimeout)}
+		}
+		out[i] = info
+	}
+	resultChan <- out
+}
+
+// pollSNMPDevice fetches scalar metrics in one Get while the walked metrics run next to it,
+// each on its own session
+func pollSNMPDevice(ctx context.Context, device SNMPDeviceConfig) DeviceInfo {
+	info := DeviceInfo{Name: device.Name}
+
+	walks := make([][]DeviceValue, len(device.Metrics))
+	var wg sync.WaitGroup
+	for i, m := range device.Metrics {
+		if m.Walk {
+			wg.Add(1)
+			go func(i int, m SNMPMetricConfig) {
+				defer wg.Done()
+				walks[i] = walkSNMPMetric(ctx, device, m)
+			}(i, m)
+		}
+	}
+
+	scalars, err := getSNMPScalars(ctx, device)
+	wg.Wait()
+	if err != nil {
+		info.Error = snmpDeviceError(ctx, err)
+		return info
+	}
+
+	for i, m := range device.Metrics {
+		if m.Walk {
+			info.Values = append(info.Values, walks[i]...)
+			continue
+		}
+		pdu, ok := scalars[strings.TrimPrefix(m.OID, ".")]
+		if !ok || snmpMissing(pdu) {
+			info.Values = append(info.Values, DeviceValue{Label: m.Name, Value: "N/A"})
+			continue
+		}
+		info.Values = append(info.Values, DeviceValue{Label: m.Name, Value: m.format(pdu)})
+	}
+	return info
+}
+
+// getSNMPScalars reads every non-walked metric of a device
+func getSNMPScalars(ctx context.Context, device SNMPDeviceConfig) (map[string]gosnmp.SnmpPDU, error) {
+	snmp, err := NewSNMPClient(device.Target())
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+	snmp.Context = ctx
+
+	var oids []string
+	for _, m := range device.Metrics {
+		if !m.Walk {
+			oids = append(oids, strings.TrimPrefix(m.OID, "."))
+		}
+	}
+	return snmpGetAll(snmp, oids)
+}
+
+// walkSNMPMetric walks one metric's subtree and returns one value per index
+func walkSNMPMetric(ctx context.Context, device SNMPDeviceConfig, m SNMPMetricConfig) []DeviceValue {
+	snmp, err := NewSNMPClient(device.Target())
+	if err != nil {
+		return []DeviceValue{{Label: m.Name, Value: "❌ " + err.Error()}}
+	}
+	defer snmp.Conn.Close()
+	snmp.Context = ctx
+
+	pdus, err := snmp.BulkWalkAll(m.OID)
+	if err != nil {
+		return []DeviceValue{{Label: m.Name, Value: "❌ " + snmpDeviceError(ctx, err)}}
+	}
+	base := strings.TrimPrefix(m.OID, ".") + "."
+	values := make([]DeviceValue, 0, len(pdus))
+	for _, pdu := range pdus {
+		index := strings.TrimPrefix(strings.TrimPrefix(pdu.Name, "."), base)
+		values = append(values, DeviceValue{
+			Label: fmt.Sprintf("%s[%s]", m.Name, index),
+			Value: m.format(pdu),
+		})
+	}
+	return values
+}
+
+// snmpDeviceError names the poll deadline instead of a bare context error
+func snmpDeviceError(ctx context.Context, err error) string {
+	if ctx.Err() != nil {
+		return fmt.Sprintf("no answer within %s", snmpDevicesTimeout)
+	}
+	return err.Error()
+}
+
+// format converts a raw PDU according to the metric's type, scale and unit
+func (m SNMPMetricConfig) format(pdu gosnmp.SnmpPDU) string {
+	suffix := ""
+	if m.Unit != "" {
+		suffix = " " + m.Unit
+	}
+
+	switch m.Type {
+	case "string":
+		return snmpString(pdu) + suffix
+	case "timeticks":
+		return formatUptime(uint32(gosnmp.ToBigInt(pdu.Value).Uint64()))
+	case "enum":
+		raw := fmt.Sprint(pdu.Value)
+		if pdu.Type == gosnmp.OctetString {
+			raw = snmpString(pdu)
+		}
+		if label, ok := m.Enum[raw]; ok {
+			return label
+		}
+		return raw
+	}
+
+	// Numbers: octet strings such as "23.5" are parsed, integers are converted directly
+	var n float64
+	if pdu.Type == gosnmp.OctetString {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(snmpString(pdu)), 64)
+		if err != nil {
+			return snmpString(pdu) + suffix
+		}
+		n = parsed
+	} else {
+		n, _ = new(big.Float).SetInt(gosnmp.ToBigInt(pdu.Value)).Float64()
+	}
+	if m.Scale != 0 {
+		n *= m.Scale
+	}
+	return strconv.FormatFloat(roundFloat(n, 2), 'f', -1, 64) + suffix
+}
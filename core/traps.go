@@ -0,0 +1,162 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// Well-known trap OIDs (SNMPv2-MIB, IF-MIB, MIKROTIK-MIB)
+const (
+	oidSnmpTrapOID    = "1.3.6.1.6.3.1.1.4.1.0"
+	oidSysUpTimeTrap  = "1.3.6.1.2.1.1.3.0"
+	oidIfIndex        = "1.3.6.1.2.1.2.2.1.1"
+	oidMikroTikPrefix = "1.3.6.1.4.1.14988"
+)
+
+// trapNames maps trap OIDs to a short label
+var trapNames = map[string]string{
+	"1.3.6.1.6.3.1.1.5.1":     "coldStart",
+	"1.3.6.1.6.3.1.1.5.2":     "warmStart",
+	"1.3.6.1.6.3.1.1.5.3":     "linkDown",
+	"1.3.6.1.6.3.1.1.5.4":     "linkUp",
+	"1.3.6.1.6.3.1.1.5.5":     "authenticationFailure",
+	"1.3.6.1.4.1.14988.1.0.1": "mtxrTemperatureException",
+}
+
+// v1GenericTraps maps SNMPv1 generic-trap numbers onto the v2 trap OIDs above
+var v1GenericTraps = map[int]string{
+	0: "1.3.6.1.6.3.1.1.5.1",
+	1: "1.3.6.1.6.3.1.1.5.2",
+	2: "1.3.6.1.6.3.1.1.5.3",
+	3: "1.3.6.1.6.3.1.1.5.4",
+	4: "1.3.6.1.6.3.1.1.5.5",
+}
+
+// StartTrapReceiver listens for SNMP traps on TRAP_LISTEN and forwards them as notifications.
+// It does nothing when TRAP_LISTEN is empty.
+func StartTrapReceiver() {
+	if TrapListen == "" {
+		return
+	}
+
+	params := &gosnmp.GoSNMP{
+		Version:   gosnmp.Version2c,
+		Community: TrapTarget.Community,
+		Logger:    gosnmp.NewLogger(log.New(log.Writer(), "snmptrap: ", 0)),
+	}
+	if TrapTarget.Version == "3" {
+		flags, usm, err := usmParams(TrapTarget)
+		if err != nil {
+			log.Printf("⚠️  SNMP trap receiver disabled: %v", err)
+			return
+		}
+		// Traps are sent by the agent, so keys are localized with the sender's engine ID
+		if TrapEngineID != "" {
+			id, err := hex.DecodeString(strings.TrimPrefix(TrapEngineID, "0x"))
+			if err != nil {
+				log.Printf("⚠️  SNMP trap receiver disabled: bad TRAP_SNMP_ENGINE_ID: %v", err)
+				return
+			}
+			usm.AuthoritativeEngineID = string(id)
+		}
+		params.Version = gosnmp.Version3
+		params.SecurityModel = gosnmp.UserSecurityModel
+		params.MsgFlags = flags
+		params.SecurityParameters = usm
+	}
+
+	listener := gosnmp.NewTrapListener()
+	listener.Params = params
+	listener.OnNewTrap = handleTrap
+
+	go func() {
+		log.Printf("📨 SNMP trap receiver listening on %s (v%s)", TrapListen, TrapTarget.Version)
+		if err := listener.Listen(TrapListen); err != nil {
+			log.Printf("❌ SNMP trap receiver stopped: %v", err)
+		}
+	}()
+}
+
+// handleTrap validates a trap and posts it to the chats
+func handleTrap(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+	// v3 packets are authenticated by gosnmp; v1/v2c must carry the configured community
+	if packet.Version != gosnmp.Version3 && TrapTarget.Community != "" && packet.Community != TrapTarget.Community {
+		log.Printf("⚠️  Dropped SNMP trap from %s: wrong community", addr.IP)
+		return
+	}
+	Notify(FormatTrap(packet, addr.IP.String()))
+}
+
+// FormatTrap renders a trap as a short human-readable notification
+func FormatTrap(packet *gosnmp.SnmpPacket, source string) string {
+	trapOID := ""
+	if packet.Version == gosnmp.Version1 {
+		trapOID = v1GenericTraps[packet.GenericTrap]
+		if packet.GenericTrap == 6 {
+			// enterpriseSpecific: enterprise.0.specific, as in RFC 3584
+			trapOID = fmt.Sprintf("%s.0.%d", strings.TrimPrefix(packet.Enterprise, "."), packet.SpecificTrap)
+		}
+	}
+
+	var details []string
+	ifIndex := 0
+	for _, v := range packet.Variables {
+		name := strings.TrimPrefix(v.Name, ".")
+		switch {
+		case name == oidSnmpTrapOID:
+			trapOID = strings.TrimPrefix(fmt.Sprint(v.Value), ".")
+		case name == oidSysUpTimeTrap:
+			// Not interesting in chat
+		case strings.HasPrefix(name, oidIfIndex+"."):
+			ifIndex = int(gosnmp.ToBigInt(v.Value).Int64())
+		default:
+			details = append(details, fmt.Sprintf("%s = %s", name, trapValue(v)))
+		}
+	}
+
+	label, known := trapNames[trapOID]
+	if !known {
+		label = trapOID
+		if strings.HasPrefix(trapOID, oidMikroTikPrefix) {
+			label = "MikroTik " + trapOID
+		}
+	}
+
+	icon := "📨"
+	switch label {
+	case "linkDown", "authenticationFailure", "mtxrTemperatureException":
+		icon = "🔴"
+	case "linkUp":
+		icon = "🟢"
+	case "coldStart", "warmStart":
+		icon = "🔁"
+	}
+
+	text := fmt.Sprintf("%s SNMP trap từ %s: %s", icon, source, label)
+	if ifIndex > 0 {
+		name := InterfaceName(ifIndex)
+		if name == "" {
+			name = "ifIndex " + strconv.Itoa(ifIndex)
+		}
+		text += " (" + name + ")"
+	}
+	// Interface traps are self-explanatory; show the varbinds for everything else
+	if ifIndex == 0 && len(details) > 0 {
+		text += "\n" + strings.Join(details, "\n")
+	}
+	return text
+}
+
+// trapValue renders a varbind value, decoding octet strings as text
+func trapValue(pdu gosnmp.SnmpPDU) string {
+	if pdu.Type == gosnmp.OctetString {
+		return snmpString(pdu)
+	}
+	return fmt.Sprint(pdu.Value)
+}
@@ -1,14 +1,23 @@
 package core
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // DashboardData aggregates all monitoring data
 type DashboardData struct {
-	MikroTik  MikroTikInfo
-	Proxmox   ProxmoxInfo
-	Singbox   SingboxInfo
-	PPPoE     PPPoESpeed
-	Timestamp string
+	MikroTik   MikroTikInfo
+	Proxmox    ProxmoxInfo
+	Singbox    SingboxInfo
+	PPPoE      PPPoESpeed
+	Interfaces []InterfaceStats
+	Devices    []DeviceInfo // Generic SNMP devices from SNMP_DEVICES_FILE
+	Timestamp  string
+
+	// View state for the paginated Proxmox section, carried in button IDs
+	VMPage      int
+	StoppedOnly bool
 }
 
 // MikroTikInfo contains MikroTik router information
@@ -17,41 +26,199 @@ type MikroTikInfo struct {
 	CPU    string
 	RAM    string
 	Uptime string
-	Error  string
+
+	// Filled by the RouterOS API collector
+	Version    string
+	Board      string
+	Interfaces []RouterInterface
+	Addresses  []IPAddress
+	PPPActive  []PPPSession
+	Health     []HealthSensor
+
+	Error string
+}
+
+// RouterInterface is one entry of the RouterOS /interface menu
+type RouterInterface struct {
+	Name     string
+	Type     string
+	Running  bool
+	Disabled bool
+}
+
+// IPAddress is one entry of the RouterOS /ip/address menu
+type IPAddress struct {
+	Address   string // CIDR, e.g. 192.168.88.1/24
+	Interface string
+}
+
+// PPPSession is one entry of the RouterOS /ppp/active menu
+type PPPSession struct {
+	Name    string
+	Service string
+	Address string
+	Uptime  string
+}
+
+// HealthSensor is one board health reading (temperature, voltage, fan...)
+type HealthSensor struct {
+	Name   string
+	Value  string
+	Unit   string
+	Number float64 // Value as a number, for alert rules
 }
 
 // ProxmoxInfo contains Proxmox VE information
 type ProxmoxInfo struct {
 	Node   string
 	Uptime string
+	Host   NodeStatus
 	VMs    []VMInfo
 	Error  string
 }
 
+// NodeStatus contains Proxmox host metrics from /nodes/{node}/status
+type NodeStatus struct {
+	CPU            float64 // CPU usage in percent
+	CPUs           int     // Logical cores, 0 if not reported
+	LoadAvg        string  // "1m 5m 15m"
+	MemUsed        uint64  // Bytes
+	MemTotal       uint64
+	SwapUsed       uint64
+	SwapTotal      uint64
+	RootUsed       uint64
+	RootTotal      uint64
+	Kernel         string
+	PVEVersion     string
+	PendingUpdates int
+	UpdatesChecked bool // false if /apt/update was not readable
+	Error          string
+}
+
+// CPUUsage renders CPU usage with the core count, e.g. "12.5% / 8 cores"
+func (n NodeStatus) CPUUsage() string {
+	if n.CPUs <= 0 {
+		return fmt.Sprintf("%.1f%%", n.CPU)
+	}
+	return fmt.Sprintf("%.1f%% / %d cores", n.CPU, n.CPUs)
+}
+
 // VMInfo contains VM/container information
 type VMInfo struct {
+	VMID   int
 	Name   string
+	Node   string
 	Type   string // "qemu" or "lxc"
 	Status string // "running" or "stopped"
+	Pool   string
+	Tags   []string
 }
 
-// SingboxInfo contains Sing-box VPN information
+// SingboxInfo contains Sing-box VPN information.
+// CurrentNode/AllNodes/NodeDelays mirror the first exposed group.
 type SingboxInfo struct {
 	CurrentNode string
 	AllNodes    []string
 	NodeDelays  map[string]int
-	Error       string
+	Groups      []ProxyGroup
+	Mode        string // Clash routing mode: rule, global or direct
+
+	// Proxy traffic from /traffic and /connections (bytes, bytes/s)
+	UpRate        uint64
+	DownRate      uint64
+	UploadTotal   uint64
+	DownloadTotal uint64
+	Connections   int
+
+	Schedule []ScheduleSlot // Next NODE_SCHEDULE switch of each scheduled group
+
+	Error string
+}
+
+// ProxyGroup is a Selector or URLTest outbound exposed by the Clash API
+type ProxyGroup struct {
+	Name   string
+	Type   string // "Selector" or "URLTest"
+	Now    string
+	All    []string
+	Delays map[string]int
+}
+
+// Switchable reports whether the group accepts a manual selection (URLTest picks by itself)
+func (g ProxyGroup) Switchable() bool {
+	return g.Type == "Selector"
 }
 
 // PPPoESpeed contains PPPoE bandwidth information
 type PPPoESpeed struct {
 	RxSpeed float64 // Download speed in Mbps
 	TxSpeed float64 // Upload speed in Mbps
-	Error   string
+	Windows []RateWindow
+
+	PublicIP        string    // Last known WAN address
+	PublicIPChanged time.Time // When PublicIP was first seen (the bot's startup for the first address)
+	Error           string
+}
+
+// InterfaceStats contains throughput and IF-MIB error counts for one interface
+type InterfaceStats struct {
+	Name        string
+	RxSpeed     float64 // Mbps
+	TxSpeed     float64 // Mbps
+	InErrors    uint64  // Errors and discards counted within ErrorWindow, not since boot
+	OutErrors   uint64
+	InDiscards  uint64
+	OutDiscards uint64
+	ErrorWindow time.Duration
+	Windows     []RateWindow
+	History     RateWindow // Average and busiest minute over the recorded history (up to 24h)
+	Error       string
+}
+
+// DeviceInfo is the polled state of one generic SNMP device
+type DeviceInfo struct {
+	Name   string
+	Values []DeviceValue
+	Error  string
+}
+
+// DeviceValue is one converted metric reading
+type DeviceValue struct {
+	Label string
+	Value string // Formatted with unit
 }
 
 // GetVietnamTime returns current time in Vietnam timezone (UTC+7)
 func GetVietnamTime() string {
-	loc := time.FixedZone("UTC+7", 7*60*60)
-	return time.Now().In(loc).Format("15:04:05")
+	return time.Now().In(vietnamTZ).Format("15:04:05")
+}
+
+// FormatVietnamTime renders t as hour and date in Vietnam time, e.g. "08:15 19/10"
+func FormatVietnamTime(t time.Time) string {
+	return t.In(vietnamTZ).Format("15:04 02/01")
+}
+
+// vietnamTZ is the fixed UTC+7 zone used for every timestamp shown in chat
+var vietnamTZ = time.FixedZone("UTC+7", 7*60*60)
+
+// FormatBytes renders a byte count with a binary unit suffix (e.g. "3.2 GiB")
+func FormatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// Percent returns used/total as a percentage, or 0 if total is 0
+func Percent(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return roundFloat(float64(used)*100/float64(total), 1)
 }
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// VMFilter holds include/exclude rules for guests shown on the dashboard.
+// Empty include lists match everything; exclude rules always win.
+type VMFilter struct {
+	IncludeTags  []string
+	ExcludeTags  []string
+	IncludePools []string
+	ExcludePools []string
+	IncludeNames []string // Glob patterns, e.g. "k8s-*"
+	ExcludeNames []string
+}
+
+// VMGroup is a named set of guests rendered under one heading
+type VMGroup struct {
+	Name string
+	VMs  []VMInfo
+}
+
+// Match reports whether vm passes the filter
+func (f VMFilter) Match(vm VMInfo) bool {
+	if anyTag(vm.Tags, f.ExcludeTags) || contains(f.ExcludePools, vm.Pool) || matchGlob(f.ExcludeNames, vm.Name) {
+		return false
+	}
+	if len(f.IncludeTags) > 0 && !anyTag(vm.Tags, f.IncludeTags) {
+		return false
+	}
+	if len(f.IncludePools) > 0 && !contains(f.IncludePools, vm.Pool) {
+		return false
+	}
+	if len(f.IncludeNames) > 0 && !matchGlob(f.IncludeNames, vm.Name) {
+		return false
+	}
+	return true
+}
+
+// FilterVMs returns the guests that pass the configured filter
+func FilterVMs(vms []VMInfo, f VMFilter) []VMInfo {
+	out := make([]VMInfo, 0, len(vms))
+	for _, vm := range vms {
+		if f.Match(vm) {
+			out = append(out, vm)
+		}
+	}
+	return out
+}
+
+// GroupVMs groups guests by "tag", "pool" or not at all (single unnamed group).
+// Guests with several tags are listed under their first tag.
+func GroupVMs(vms []VMInfo, groupBy string, stoppedOnly bool) []VMGroup {
+	groups := make(map[string][]VMInfo)
+	var order []string
+
+	for _, vm := range vms {
+		if stoppedOnly && vm.Status == "running" {
+			continue
+		}
+
+		key := ""
+		switch groupBy {
+		case "tag":
+			key = "untagged"
+			if len(vm.Tags) > 0 {
+				key = vm.Tags[0]
+			}
+		case "pool":
+			key = vm.Pool
+			if key == "" {
+				key = "no pool"
+			}
+		}
+
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], vm)
+	}
+
+	sort.Strings(order)
+	result := make([]VMGroup, 0, len(order))
+	for _, key := range order {
+		result = append(result, VMGroup{Name: key, VMs: groups[key]})
+	}
+	return result
+}
+
+// PaginateLines splits lines into pages whose joined length stays within limit bytes.
+// A single line longer than limit gets a page of its own.
+func PaginateLines(lines []string, limit int) [][]string {
+	var pages [][]string
+	var current []string
+	size := 0
+
+	for _, line := range lines {
+		if len(current) > 0 && size+len(line)+1 > limit {
+			pages = append(pages, current)
+			current = nil
+			size = 0
+		}
+		current = append(current, line)
+		size += len(line) + 1
+	}
+	if len(current) > 0 || len(pages) == 0 {
+		pages = append(pages, current)
+	}
+	return pages
+}
+
+// ClampPage keeps a requested page index within [0, total)
+func ClampPage(page, total int) int {
+	if page >= total {
+		page = total - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	return page
+}
+
+// splitList parses a comma-separated env value, dropping empty items
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyTag(tags, wanted []string) bool {
+	for _, t := range tags {
+		if contains(wanted, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPaginateLines(t *testing.T) {
+	long := strings.Repeat("x", 20)
+	tests := []struct {
+		name  string
+		lines []string
+		limit int
+		want  [][]string
+	}{
+		{"empty input gives one empty page", nil, 10, [][]string{nil}},
+		{"everything fits", []string{"ab", "cd"}, 10, [][]string{{"ab", "cd"}}},
+		{"exactly at the limit", []string{"aaaa", "bbbb"}, 10, [][]string{{"aaaa", "bbbb"}}},
+		{"split when over the limit", []string{"aaaa", "bbbb", "cccc"}, 10, [][]string{{"aaaa", "bbbb"}, {"cccc"}}},
+		{"long line gets its own page", []string{"a", long, "b"}, 10, [][]string{{"a"}, {long}, {"b"}}},
+		{"only a long line", []string{long}, 10, [][]string{{long}}},
+	}
+	for _, tt := range tests {
+		if got := PaginateLines(tt.lines, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: PaginateLines(%q, %d) = %q, want %q", tt.name, tt.lines, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{nil, "web", false},
+		{[]string{"k8s-*"}, "k8s-worker1", true},
+		{[]string{"k8s-*"}, "K8S-Master", true}, // case-insensitive
+		{[]string{"k8s-*"}, "db-k8s", false},
+		{[]string{"db?"}, "db1", true},
+		{[]string{"db?"}, "db10", false},
+		{[]string{"web", "db-*"}, "db-main", true},
+		{[]string{"[bad"}, "[bad", false}, // malformed patterns never match
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.patterns, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.patterns, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterVMs(t *testing.T) {
+	vms := []VMInfo{
+		{VMID: 100, Name: "k8s-master", Pool: "prod", Tags: []string{"k8s"}},
+		{VMID: 101, Name: "k8s-worker", Pool: "prod", Tags: []string{"k8s", "test"}},
+		{VMID: 102, Name: "db", Pool: "Prod"},
+		{VMID: 103, Name: "sandbox", Pool: "lab", Tags: []string{"test"}},
+	}
+	tests := []struct {
+		name   string
+		vms    []VMInfo
+		filter VMFilter
+		want   []int
+	}{
+		{"empty input", nil, VMFilter{IncludeTags: []string{"k8s"}}, []int{}},
+		{"empty filter keeps everything", vms, VMFilter{}, []int{100, 101, 102, 103}},
+		{"include tag", vms, VMFilter{IncludeTags: []string{"K8S"}}, []int{100, 101}},
+		{"exclude wins over include", vms, VMFilter{IncludeTags: []string{"k8s"}, ExcludeTags: []string{"test"}}, []int{100}},
+		{"include pool is case-insensitive", vms, VMFilter{IncludePools: []string{"prod"}}, []int{100, 101, 102}},
+		{"exclude pool", vms, VMFilter{ExcludePools: []string{"lab"}}, []int{100, 101, 102}},
+		{"include name glob", vms, VMFilter{IncludeNames: []string{"k8s-*"}}, []int{100, 101}},
+		{"exclude name glob", vms, VMFilter{ExcludeNames: []string{"*-worker", "db"}}, []int{100, 103}},
+		{"every include list must match", vms, VMFilter{IncludePools: []string{"prod"}, IncludeNames: []string{"db"}}, []int{102}},
+	}
+	for _, tt := range tests {
+		got := []int{}
+		for _, vm := range FilterVMs(tt.vms, tt.filter) {
+			got = append(got, vm.VMID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: FilterVMs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
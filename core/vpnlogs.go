@@ -0,0 +1,166 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevels are the /logs levels accepted by the Clash API, most verbose first
+var LogLevels = []string{"debug", "info", "warning", "error"}
+
+// logStreams holds the cancel function of each running /vpnlogs stream by chat key
+var logStreams = struct {
+	sync.Mutex
+	cancel map[string]context.CancelFunc
+}{cancel: make(map[string]context.CancelFunc)}
+
+// ErrLogStreamRunning is returned when a chat already has a log stream
+var ErrLogStreamRunning = errors.New("a log stream is already running here, stop it first")
+
+// StartLogStream relays sing-box log lines at level to flush, in batches at most once per
+// LOG_STREAM_INTERVAL, until duration elapses or StopLogStream(key) is called. It blocks and
+// returns why the stream ended.
+func StartLogStream(key, level string, duration time.Duration, flush func(lines []string)) error {
+	if !contains(LogLevels, level) {
+		return fmt.Errorf("unknown level %q (use %s)", level, strings.Join(LogLevels, ", "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	logStreams.Lock()
+	if _, running := logStreams.cancel[key]; running {
+		logStreams.Unlock()
+		return ErrLogStreamRunning
+	}
+	logStreams.cancel[key] = cancel
+	logStreams.Unlock()
+
+	defer func() {
+		logStreams.Lock()
+		delete(logStreams.cancel, key)
+		logStreams.Unlock()
+	}()
+
+	lines := make(chan string, 256)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- readSingboxLogs(ctx, level, lines)
+		close(lines)
+	}()
+
+	ticker := time.NewTicker(LogStreamInterval)
+	defer ticker.Stop()
+
+	var batch []string
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if len(batch) > 0 {
+					flush(batch)
+				}
+				err := <-streamErr
+				if ctx.Err() != nil {
+					// Timeout or /vpnlogs stop
+					return nil
+				}
+				return err
+			}
+			batch = append(batch, line)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				flush(batch)
+				batch = nil
+			}
+		}
+	}
+}
+
+// LogStreamRunning reports whether key already has a log stream
+func LogStreamRunning(key string) bool {
+	logStreams.Lock()
+	defer logStreams.Unlock()
+	_, ok := logStreams.cancel[key]
+	return ok
+}
+
+// StopLogStream ends the stream running for key and reports whether there was one
+func StopLogStream(key string) bool {
+	logStreams.Lock()
+	defer logStreams.Unlock()
+	cancel, ok := logStreams.cancel[key]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// readSingboxLogs sends each /logs entry as "[level] payload" until the stream or ctx ends
+func readSingboxLogs(ctx context.Context, level string, lines chan<- string) error {
+	// No client timeout: the stream is bounded by ctx instead
+	client, err := NewHTTPClient(0, SingboxTLS)
+	if err != nil {
+		return err
+	}
+	resp, err := singboxDo(ctx, client, "GET", "/logs?"+url.Values{"level": {level}}.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var entry struct {
+			Type    string `json:"type"`
+			Payload string `json:"payload"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		select {
+		case lines <- fmt.Sprintf("[%s] %s", entry.Type, entry.Payload):
+		default:
+			// The chat side is slower than sing-box; drop lines rather than block the stream
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return errors.New("log stream closed by sing-box")
+}
+
+// PackLogLines joins lines into a chunk of at most limit bytes, keeping the newest lines
+// and noting how many older ones were dropped
+func PackLogLines(lines []string, limit int) string {
+	size, start := 0, len(lines)
+	for start > 0 && size+len(lines[start-1])+1 <= limit-40 {
+		start--
+		size += len(lines[start]) + 1
+	}
+	text := strings.Join(lines[start:], "\n")
+	if start > 0 {
+		text = fmt.Sprintf("… (bỏ qua %d dòng)\n", start) + text
+	}
+	return text
+}
+
+// LogStreamLength turns a requested length in minutes into a stream duration, using
+// LOG_STREAM_DURATION when unset and capping it at LOG_STREAM_MAX_DURATION
+func LogStreamLength(minutes int) time.Duration {
+	d := LogStreamDuration
+	if minutes > 0 {
+		d = time.Duration(minutes) * time.Minute
+	}
+	if d > LogStreamMaxDuration {
+		d = LogStreamMaxDuration
+	}
+	return d
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WANReconnectResult describes a PPPoE bounce
+type WANReconnectResult struct {
+	Interface string
+	OldIP     string
+	NewIP     string
+	Recovery  time.Duration // From enable until the session had an address again
+}
+
+// ReconnectPPPoE disables and re-enables the PPPoE client over the RouterOS API,
+// then waits until the interface has an address again.
+func ReconnectPPPoE(ctx context.Context, progress func(string)) (WANReconnectResult, error) {
+	result := WANReconnectResult{Interface: PPPoEInterface}
+	report := func(msg string) {
+		if progress != nil {
+			progress(msg)
+		}
+	}
+
+	api, err := DialMikroTikAPI(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer api.Close()
+
+	rows, err := api.Run("/interface/pppoe-client/print", "?name="+PPPoEInterface, "=.proplist=.id,name")
+	if err != nil {
+		return result, err
+	}
+	if len(rows) == 0 {
+		return result, fmt.Errorf("PPPoE client %q not found", PPPoEInterface)
+	}
+	id := rows[0][".id"]
+
+	result.OldIP, _ = interfaceAddress(api, PPPoEInterface)
+
+	report("⏸ Disabling " + PPPoEInterface)
+	if _, err := api.Run("/interface/pppoe-client/disable", "=numbers="+id); err != nil {
+		return result, err
+	}
+
+	select {
+	case <-ctx.Done():
+		// Never leave the WAN disabled because the caller gave up
+		if err := forceEnablePPPoE(id); err != nil {
+			return result, fmt.Errorf("%w; re-enabling %s failed: %v", ctx.Err(), PPPoEInterface, err)
+		}
+		return result, ctx.Err()
+	case <-time.After(2 * time.Second):
+	}
+
+	report("▶️ Enabling " + PPPoEInterface)
+	if _, err := api.Run("/interface/pppoe-client/enable", "=numbers="+id); err != nil {
+		// The session may be broken (timeout, dropped connection): retry on a new one
+		report("⚠️ Enable failed (" + err.Error() + "), retrying")
+		if err := forceEnablePPPoE(id); err != nil {
+			return result, fmt.Errorf("%s is still disabled: %w", PPPoEInterface, err)
+		}
+		// Wait for the session on a new connection too
+		if api, err = DialMikroTikAPI(ctx); err != nil {
+			return result, err
+		}
+		defer api.Close()
+	}
+	enabledAt := time.Now()
+
+	deadline := time.NewTimer(WANReconnectTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-deadline.C:
+			return result, fmt.Errorf("PPPoE did not reconnect within %s", WANReconnectTimeout)
+		case <-ticker.C:
+		}
+
+		if ip, err := interfaceAddress(api, PPPoEInterface); err == nil && ip != "" {
+			result.NewIP = ip
+			result.Recovery = time.Since(enabledAt)
+			InvalidateInterface(PPPoEInterface)
+			RecordPublicIP(ip)
+			return result, nil
+		}
+		report(fmt.Sprintf("⏳ Waiting for session... %s", time.Since(enabledAt).Round(time.Second)))
+	}
+}
+
+// forceEnablePPPoE re-enables the PPPoE client on fresh API sessions, independent of the
+// caller's context, so a failed or cancelled reconnect never leaves the WAN disabled
+func forceEnablePPPoE(id string) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			time.Sleep(2 * time.Second)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		var api *RouterOSClient
+		if api, err = DialMikroTikAPI(ctx); err == nil {
+			_, err = api.Run("/interface/pppoe-client/enable", "=numbers="+id)
+			api.Close()
+		}
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// interfaceAddress returns the first IPv4 address (without prefix length) on an interface
+func interfaceAddress(api *RouterOSClient, iface string) (string, error) {
+	rows, err := api.Run("/ip/address/print", "?interface="+iface, "=.proplist=address")
+	if err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		if addr := row["address"]; addr != "" {
+			return strings.SplitN(addr, "/", 2)[0], nil
+		}
+	}
+	return "", nil
+}
+
+// FormatWANReconnect renders a reconnect result for both bots
+func FormatWANReconnect(r WANReconnectResult) string {
+	changed := "không đổi"
+	if r.OldIP != r.NewIP {
+		changed = fmt.Sprintf("%s → %s", valOrNA(r.OldIP), r.NewIP)
+	}
+	return fmt.Sprintf("✅ %s đã kết nối lại sau %s\nIP: %s (%s)",
+		r.Interface, r.Recovery.Round(100*time.Millisecond), r.NewIP, changed)
+}
+
+func valOrNA(s string) string {
+	if s == "" {
+		return "N/A"
+	}
+	return s
+}
@@ -42,25 +42,89 @@ func HandleStatusCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 // HandleButtonCallback handles button clicks (node selection and refresh)
 func HandleButtonCallback(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 	callback := update.CallbackQuery
-	data := callback.Data
 
 	// Verify authorization? (Assuming handled by main loop filter, but good to have)
 	// For now we trust the caller if they can see the button
 
+	data, page, stoppedOnly, ok := splitView(callback.Data)
+	if !ok {
+		// Dashboards sent before the view state was carried open on the default view
+		page, stoppedOnly = 0, core.PVEOnlyStop
+	}
+
 	if data == "refresh" {
 		// Answer callback immediately
 		bot.Request(tgbotapi.NewCallback(callback.ID, "🔄 Đang cập nhật..."))
-	} else if strings.HasPrefix(data, "set|") {
-		nodeName := strings.TrimPrefix(data, "set|")
-		
-		// Switch node
-		err := core.SwitchNode(nodeName)
+	} else if p, stopped, ok := parsePVECallbackData(data); ok {
+		page, stoppedOnly = p, stopped
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+	} else if strings.HasPrefix(data, "mode|") {
+		mode := strings.TrimPrefix(data, "mode|")
+		if !callbackAdmin(bot, callback) {
+			return
+		}
+		if err := core.SetClashMode(context.Background(), mode); err != nil {
+			bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Lỗi: "+err.Error()))
+			return
+		}
+		bot.Request(tgbotapi.NewCallback(callback.ID, fmt.Sprintf("🧭 Đã chuyển sang mode %s", mode)))
+	} else if data == "closeconns" {
+		// Close every Sing-box connection so flows reconnect through the selected nodes
+		if !callbackAdmin(bot, callback) {
+			return
+		}
+		if err := core.CloseAllConnections(context.Background()); err != nil {
+			bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Lỗi: "+err.Error()))
+		} else {
+			bot.Request(tgbotapi.NewCallback(callback.ID, "✂️ Đã đóng toàn bộ kết nối"))
+		}
+		return
+	} else if idx, ok := parseIndexes(data, "group|", 1); ok {
+		// Open the node submenu of one group
+		info := core.FetchSingboxInfo(context.Background())
+		if idx[0] >= len(info.Groups) {
+			bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Không tìm thấy nhóm"))
+			return
+		}
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		keyboard := CreateGroupKeyboard(info.Groups[idx[0]], idx[0], viewSuffix(page, stoppedOnly))
+		bot.Send(tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID, keyboard))
+		return
+	} else if strings.HasPrefix(data, "node|") {
+		// Resolve the indexes against a fresh /proxies read; the hash catches reordered lists
+		gi, ni, hash, ok := parseNodeCallbackData(data)
+		if !ok {
+			bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Danh sách node đã thay đổi, hãy bấm Refresh"))
+			return
+		}
+		groups, err := core.ListProxyGroups(context.Background())
 		if err != nil {
 			bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Lỗi: "+err.Error()))
+			return
+		}
+		if gi >= len(groups) || ni >= len(groups[gi].All) || nodeHash(groups[gi].All[ni]) != hash {
+			bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Danh sách node đã thay đổi, hãy bấm Refresh"))
+			return
+		}
+		if !switchNode(bot, callback, groups[gi].Name, groups[gi].All[ni]) {
+			return // Don't refresh if failed
+		}
+	} else if strings.HasPrefix(data, "set|") {
+		// Dashboards sent before indexed callbacks use "set|<group>|<node>", or "set|<node>" for the first group
+		group, nodeName, ok := strings.Cut(strings.TrimPrefix(data, "set|"), "|")
+		if !ok {
+			nodeName = group
+			info := core.FetchSingboxInfo(context.Background())
+			if len(info.Groups) == 0 {
+				bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Lỗi: "+info.Error))
+				return
+			}
+			group = info.Groups[0].Name
+		}
+
+		if !switchNode(bot, callback, group, nodeName) {
 			return // Don't refresh if failed
 		}
-		
-		bot.Request(tgbotapi.NewCallback(callback.ID, fmt.Sprintf("✅ Đã chọn %s", nodeName)))
 	}
 
 	// Refresh dashboard
@@ -70,6 +134,8 @@ func HandleButtonCallback(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 		log.Println("Error updating dashboard:", err)
 		return
 	}
+	dashData.VMPage = page
+	dashData.StoppedOnly = stoppedOnly
 
 	text := FormatDashboardMessage(dashData)
 	keyboard := CreateNodeKeyboard(dashData)
@@ -86,3 +152,20 @@ func HandleButtonCallback(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 		// Ignore "message is not modified" error
 	}
 }
+
+// switchNode switches the group and answers the callback; a failure to close the old
+// connections is shown as a warning since the switch itself succeeded
+func switchNode(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery, group, nodeName string) bool {
+	if err := core.SwitchNode(group, nodeName); err != nil {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "❌ Lỗi: "+err.Error()))
+		return false
+	}
+
+	text := fmt.Sprintf("✅ %s: đã chọn %s", group, nodeName)
+	if err := core.CloseAfterSwitch(context.Background()); err != nil {
+		bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, text+"\n⚠️ "+err.Error()))
+		return true
+	}
+	bot.Request(tgbotapi.NewCallback(callback.ID, text))
+	return true
+}
@@ -2,40 +2,188 @@ package telegram
 
 import (
 	"fmt"
+	"hash/fnv"
+	"strconv"
 	"strings"
 	"super-bot/core"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// CreateNodeKeyboard creates the inline keyboard with node selection buttons
+// CreateNodeKeyboard creates the inline keyboard with node selection buttons.
+// A single selector group gets its nodes inline; several groups get one submenu button each.
 func CreateNodeKeyboard(data *core.DashboardData) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]tgbotapi.InlineKeyboardButton
-	var currentRow []tgbotapi.InlineKeyboardButton
 
-	// Create node buttons
-	for _, node := range data.Singbox.AllNodes {
-		// Display logic
-		display := strings.Replace(node, "WG-Solid-", "", -1)
-		display = strings.Replace(display, "WG-", "", -1)
+	// Indexes into data.Singbox.Groups: callback data is limited to 64 bytes, so buttons carry
+	// positions instead of group and node names
+	var groups []int
+	for gi, group := range data.Singbox.Groups {
+		if group.Switchable() {
+			groups = append(groups, gi)
+		}
+	}
 
-		// Status icon
-		icon := "🌐"
-		if node == data.Singbox.CurrentNode {
-			icon = "🟢"
+	// Every button that re-renders the dashboard carries the Proxmox view state
+	_, page, total := renderDashboard(data)
+	view := viewSuffix(page, data.StoppedOnly)
+
+	if len(groups) == 1 {
+		rows = append(rows, nodeRows(data.Singbox.Groups[groups[0]], groups[0], view)...)
+	} else {
+		for _, gi := range groups {
+			group := data.Singbox.Groups[gi]
+			label := fmt.Sprintf("⚡ %s: %s", group.Name, shortNodeName(group.Now))
+			rows = append(rows, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("group|%d", gi)+view),
+			})
 		}
+	}
+
+	// Add Clash mode buttons
+	if data.Singbox.Mode != "" {
+		var modeRow []tgbotapi.InlineKeyboardButton
+		for _, mode := range core.ClashModes {
+			label := "🧭 " + mode
+			if mode == data.Singbox.Mode {
+				label = "🟢 " + mode
+			}
+			modeRow = append(modeRow, tgbotapi.NewInlineKeyboardButtonData(label, "mode|"+mode+view))
+		}
+		rows = append(rows, modeRow)
+	}
+
+	// Add Proxmox pager and stopped-only toggle
+	var pveRow []tgbotapi.InlineKeyboardButton
+	if total > 1 {
+		if page > 0 {
+			pveRow = append(pveRow, tgbotapi.NewInlineKeyboardButtonData("⬅️ Trước", pveCallbackData(page-1, data.StoppedOnly)))
+		}
+		if page < total-1 {
+			pveRow = append(pveRow, tgbotapi.NewInlineKeyboardButtonData("Sau ➡️", pveCallbackData(page+1, data.StoppedOnly)))
+		}
+	}
+	toggleLabel := "⏹ Chỉ máy dừng"
+	if data.StoppedOnly {
+		toggleLabel = "⏹ Tất cả máy"
+	}
+	pveRow = append(pveRow, tgbotapi.NewInlineKeyboardButtonData(toggleLabel, pveCallbackData(0, !data.StoppedOnly)))
+	rows = append(rows, pveRow)
+
+	// Add Refresh button
+	refreshBtn := tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh Dashboard", "refresh"+view)
+	closeBtn := tgbotapi.NewInlineKeyboardButtonData("✂️ Ngắt kết nối", "closeconns")
+	rows = append(rows, []tgbotapi.InlineKeyboardButton{refreshBtn, closeBtn})
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// pveCallbackData encodes the Proxmox view state as "pve|<page>|<all|stopped>"
+func pveCallbackData(page int, stoppedOnly bool) string {
+	mode := "all"
+	if stoppedOnly {
+		mode = "stopped"
+	}
+	return fmt.Sprintf("pve|%d|%s", page, mode)
+}
+
+// viewSuffix is the Proxmox view state appended to other callbacks as "|pve|<page>|<all|stopped>",
+// so refresh, group, node and mode clicks re-render the page the dashboard was showing
+func viewSuffix(page int, stoppedOnly bool) string {
+	return "|" + pveCallbackData(page, stoppedOnly)
+}
+
+// splitView strips the state added by viewSuffix; ok is false for callbacks without one
+func splitView(data string) (base string, page int, stoppedOnly bool, ok bool) {
+	cut := strings.LastIndex(data, "|pve|")
+	if cut < 0 {
+		return data, 0, false, false
+	}
+	page, stoppedOnly, ok = parsePVECallbackData(data[cut+1:])
+	if !ok {
+		return data, 0, false, false
+	}
+	return data[:cut], page, stoppedOnly, true
+}
 
-		// Delay
-		delay := data.Singbox.NodeDelays[node]
-		delayStr := "N/A"
-		if delay > 0 {
-			delayStr = fmt.Sprintf("%dms", delay)
+// parseIndexes decodes the "|"-separated indexes after a callback prefix such as "node|"
+func parseIndexes(data, prefix string, n int) ([]int, bool) {
+	if !strings.HasPrefix(data, prefix) {
+		return nil, false
+	}
+	parts := strings.Split(strings.TrimPrefix(data, prefix), "|")
+	if len(parts) != n {
+		return nil, false
+	}
+	indexes := make([]int, n)
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return nil, false
 		}
+		indexes[i] = v
+	}
+	return indexes, true
+}
 
-		label := fmt.Sprintf("%s %s (%s)", icon, display, delayStr)
-		btn := tgbotapi.NewInlineKeyboardButtonData(label, "set|"+node)
+// parsePVECallbackData decodes callback data produced by pveCallbackData
+func parsePVECallbackData(data string) (page int, stoppedOnly bool, ok bool) {
+	parts := strings.Split(data, "|")
+	if len(parts) != 3 || parts[0] != "pve" {
+		return 0, false, false
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false, false
+	}
+	return page, parts[2] == "stopped", true
+}
 
-		currentRow = append(currentRow, btn)
+// CreateGroupKeyboard is the submenu listing the nodes of one selector group; gi is the
+// group's position in SingboxInfo.Groups and view the dashboard state from viewSuffix
+func CreateGroupKeyboard(group core.ProxyGroup, gi int, view string) tgbotapi.InlineKeyboardMarkup {
+	rows := nodeRows(group, gi, view)
+	rows = append(rows, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Quay lại", "refresh"+view),
+	})
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// nodeHash is a short checksum of a node name, so a click resolved against a reordered
+// group or member list is refused instead of switching to whatever now sits at the index
+func nodeHash(node string) string {
+	h := fnv.New32a()
+	h.Write([]byte(node))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// parseNodeCallbackData decodes "node|<group index>|<node index>|<node hash>"
+func parseNodeCallbackData(data string) (gi, ni int, hash string, ok bool) {
+	cut := strings.LastIndex(data, "|")
+	if cut < 0 {
+		return 0, 0, "", false
+	}
+	idx, ok := parseIndexes(data[:cut], "node|", 2)
+	if !ok {
+		return 0, 0, "", false
+	}
+	return idx[0], idx[1], data[cut+1:], true
+}
+
+// nodeRows renders a group's nodes as "node|<group index>|<node index>|<node hash>" buttons, 2 per row
+func nodeRows(group core.ProxyGroup, gi int, view string) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var currentRow []tgbotapi.InlineKeyboardButton
+
+	for ni, node := range group.All {
+		// Status icon
+		icon := "🌐"
+		if node == group.Now {
+			icon = "🟢"
+		}
+
+		label := fmt.Sprintf("%s %s (%s)", icon, shortNodeName(node), core.FormatDelay(group.Delays[node]))
+		currentRow = append(currentRow, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("node|%d|%d|%s", gi, ni, nodeHash(node))+view))
 
 		// 2 buttons per row
 		if len(currentRow) == 2 {
@@ -48,10 +196,11 @@ func CreateNodeKeyboard(data *core.DashboardData) tgbotapi.InlineKeyboardMarkup
 	if len(currentRow) > 0 {
 		rows = append(rows, currentRow)
 	}
+	return rows
+}
 
-	// Add Refresh button
-	refreshBtn := tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh Dashboard", "refresh")
-	rows = append(rows, []tgbotapi.InlineKeyboardButton{refreshBtn})
-
-	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+// shortNodeName strips the common WireGuard prefixes from a node name
+func shortNodeName(node string) string {
+	display := strings.Replace(node, "WG-Solid-", "", -1)
+	return strings.Replace(display, "WG-", "", -1)
 }
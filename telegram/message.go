@@ -4,10 +4,26 @@ import (
 	"fmt"
 	"strings"
 	"super-bot/core"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Telegram rejects messages longer than 4096 characters
+	messageLimit = 4096
+	// Room reserved for the "Trang x/y" line
+	pagerFooterLen = 24
 )
 
 // FormatDashboardMessage formats the dashboard data into a Telegram Markdown message
 func FormatDashboardMessage(data *core.DashboardData) string {
+	text, _, _ := renderDashboard(data)
+	return text
+}
+
+// renderDashboard builds the message for the current Proxmox page and returns the page position.
+// The guest list gets whatever room the other sections leave under the message limit.
+func renderDashboard(data *core.DashboardData) (text string, page, total int) {
 	var sb strings.Builder
 
 	// Helper to handle empty strings
@@ -25,19 +41,22 @@ func FormatDashboardMessage(data *core.DashboardData) string {
 		sb.WriteString(fmt.Sprintf("🏗 *PROXMOX VE:* `%s`\n", val(data.Proxmox.Node)))
 		sb.WriteString(fmt.Sprintf("⏱️ Uptime: `%s`\n", val(data.Proxmox.Uptime)))
 
-		for _, vm := range data.Proxmox.VMs {
-			icon := "📦"
-			if vm.Type == "qemu" {
-				icon = "🖥"
+		if host := data.Proxmox.Host; host.Error == "" {
+			sb.WriteString(fmt.Sprintf("📊 CPU: `%s` | Load: `%s`\n", host.CPUUsage(), val(host.LoadAvg)))
+			sb.WriteString(fmt.Sprintf("🧠 RAM: `%s/%s (%.0f%%)` | Swap: `%s/%s`\n",
+				core.FormatBytes(host.MemUsed), core.FormatBytes(host.MemTotal), core.Percent(host.MemUsed, host.MemTotal),
+				core.FormatBytes(host.SwapUsed), core.FormatBytes(host.SwapTotal)))
+			sb.WriteString(fmt.Sprintf("💾 Root: `%s/%s`\n", core.FormatBytes(host.RootUsed), core.FormatBytes(host.RootTotal)))
+			sb.WriteString(fmt.Sprintf("🐧 PVE `%s` | Kernel `%s`\n", val(host.PVEVersion), val(host.Kernel)))
+			if host.UpdatesChecked && host.PendingUpdates > 0 {
+				sb.WriteString(fmt.Sprintf("📦 Updates: `%d pending`\n", host.PendingUpdates))
 			}
-			status := "❌"
-			if vm.Status == "running" {
-				status = "✅"
-			}
-			sb.WriteString(fmt.Sprintf(" • %s %s: %s\n", icon, vm.Name, status))
 		}
 	}
 
+	head := sb.String()
+	sb.Reset()
+
 	sb.WriteString("----------------------------\n")
 
 	// --- MikroTik Section ---
@@ -47,6 +66,14 @@ func FormatDashboardMessage(data *core.DashboardData) string {
 		sb.WriteString(fmt.Sprintf("📟 *MIKROTIK:* `%s`\n", val(data.MikroTik.Name)))
 		sb.WriteString(fmt.Sprintf("📊 CPU: `%s%%` | RAM: `%s`\n", val(data.MikroTik.CPU), val(data.MikroTik.RAM)))
 		sb.WriteString(fmt.Sprintf("⏱ Uptime: `%s`\n", val(data.MikroTik.Uptime)))
+		if mt := data.MikroTik; mt.Version != "" {
+			running, enabled := core.RunningInterfaces(mt.Interfaces)
+			sb.WriteString(fmt.Sprintf("🧩 RouterOS `%s` (%s) | IF `%d/%d` | PPP `%d`\n",
+				mt.Version, mt.Board, running, enabled, len(mt.PPPActive)))
+		}
+		if len(data.MikroTik.Health) > 0 {
+			sb.WriteString(fmt.Sprintf("🌡 Health: `%s`\n", core.FormatHealth(data.MikroTik.Health)))
+		}
 	}
 
 	// --- PPPoE Section ---
@@ -54,19 +81,126 @@ func FormatDashboardMessage(data *core.DashboardData) string {
 		sb.WriteString("🌐 PPPoE: ❌ Lỗi kết nối\n")
 	} else {
 		sb.WriteString(fmt.Sprintf("🌐 PPPoE: ↓ `%.2f Mbps` | ↑ `%.2f Mbps`\n", data.PPPoE.RxSpeed, data.PPPoE.TxSpeed))
+		if data.PPPoE.PublicIP != "" {
+			sb.WriteString(fmt.Sprintf("🌍 WAN IP: `%s` (từ %s)\n", data.PPPoE.PublicIP, core.FormatVietnamTime(data.PPPoE.PublicIPChanged)))
+		}
+		if n := len(data.PPPoE.Windows); n > 0 {
+			w := data.PPPoE.Windows[n-1]
+			sb.WriteString(fmt.Sprintf("📈 %s: TB ↓ `%.2f` ↑ `%.2f` | Đỉnh ↓ `%.2f` ↑ `%.2f` Mbps\n",
+				w.Window, w.RxAvg, w.TxAvg, w.RxPeak, w.TxPeak))
+		}
 	}
 
+	// --- Bandwidth Section ---
+	if len(data.Interfaces) > 0 {
+		sb.WriteString("📶 *Bandwidth:*\n```\n" + core.FormatBandwidthTable(data.Interfaces) + "```\n")
+	}
+
+	// --- Generic SNMP Devices ---
+	// Keep them to a share of the message so the guest list still gets room
+	sb.WriteString(deviceSection(data.Devices, messageLimit/4))
+
 	sb.WriteString("----------------------------\n")
 
 	// --- Sing-box Section ---
 	if data.Singbox.Error != "" {
 		sb.WriteString(fmt.Sprintf("⚡️ *Sing-box:* ❌ Lỗi: %s\n", data.Singbox.Error))
 	} else {
-		sb.WriteString(fmt.Sprintf("⚡️ *Đang Chọn:* `%s`\n", val(data.Singbox.CurrentNode)))
+		if data.Singbox.Mode != "" {
+			sb.WriteString(fmt.Sprintf("🧭 Mode: `%s`\n", data.Singbox.Mode))
+		}
+		for _, group := range data.Singbox.Groups {
+			mode := ""
+			if !group.Switchable() {
+				mode = " (tự động)"
+			}
+			sb.WriteString(fmt.Sprintf("⚡️ *%s%s:* `%s`\n", group.Name, mode, val(group.Now)))
+		}
+		sb.WriteString(fmt.Sprintf("📡 VPN: `%s`\n", core.FormatTrafficLine(data.Singbox)))
+		for _, slot := range data.Singbox.Schedule {
+			sb.WriteString(fmt.Sprintf("⏰ Lịch: `%s`\n", core.FormatScheduleSlot(slot)))
+		}
 	}
 
 	// Footer
 	sb.WriteString(fmt.Sprintf("🕒 _Cập nhật lúc: %s_", data.Timestamp))
+	tail := sb.String()
+
+	if data.Proxmox.Error != "" {
+		return head + tail, 0, 1
+	}
+
+	pages := vmPages(data, messageLimit-len(head)-len(tail)-pagerFooterLen)
+	page = core.ClampPage(data.VMPage, len(pages))
+	vms := strings.Join(pages[page], "\n")
+	if vms != "" {
+		vms += "\n"
+	}
+	if len(pages) > 1 {
+		vms += fmt.Sprintf("_Trang %d/%d_\n", page+1, len(pages))
+	}
+
+	return head + vms + tail, page, len(pages)
+}
+
+// vmPages renders the (filtered, grouped) guest list and splits it into pages of at most budget bytes
+func vmPages(data *core.DashboardData, budget int) [][]string {
+	var lines []string
+	for _, group := range core.GroupVMs(data.Proxmox.VMs, core.PVEGroupBy, data.StoppedOnly) {
+		if group.Name != "" {
+			lines = append(lines, fmt.Sprintf("*%s*", escapeMarkdown(group.Name)))
+		}
+		for _, vm := range group.VMs {
+			icon := "📦"
+			if vm.Type == "qemu" {
+				icon = "🖥"
+			}
+			status := "❌"
+			if vm.Status == "running" {
+				status = "✅"
+			}
+			lines = append(lines, fmt.Sprintf(" • %s %s: %s", icon, escapeMarkdown(vm.Name), status))
+		}
+	}
+	if len(lines) == 0 && data.StoppedOnly {
+		lines = append(lines, "_Không có máy nào đang dừng_")
+	}
+	return core.PaginateLines(lines, budget)
+}
+
+// deviceSection renders the generic SNMP devices in whole lines of at most budget bytes,
+// noting how many lines were left out
+func deviceSection(devices []core.DeviceInfo, budget int) string {
+	var lines []string
+	for _, device := range devices {
+		name := escapeMarkdown(device.Name)
+		if device.Error != "" {
+			lines = append(lines, fmt.Sprintf("🔌 *%s:* ❌ Lỗi: %s", name, escapeMarkdown(truncate(device.Error, 200))))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("🔌 *%s*", name))
+		for _, v := range device.Values {
+			lines = append(lines, fmt.Sprintf(" • %s: `%s`", escapeMarkdown(v.Label), codeSpan(v.Value)))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	pages := core.PaginateLines(lines, budget-pagerFooterLen)
+	text := strings.Join(pages[0], "\n") + "\n"
+	if omitted := len(lines) - len(pages[0]); omitted > 0 {
+		text += fmt.Sprintf("_… bỏ qua %d dòng_\n", omitted)
+	}
+	return text
+}
+
+// escapeMarkdown escapes the Markdown control characters of text shown outside code spans
+func escapeMarkdown(text string) string {
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text)
+}
 
-	return sb.String()
+// codeSpan makes text safe inside a `code` span, where Markdown offers no escaping
+func codeSpan(text string) string {
+	return strings.ReplaceAll(text, "`", "'")
 }
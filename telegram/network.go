@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"super-bot/core"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleInterfacesCommand handles the /interfaces command
+func HandleInterfacesCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	ifaces, err := core.DiscoverInterfaces(ctx)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Lỗi SNMP: "+err.Error()))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "📡 *Interfaces*\n```\n"+truncate(core.FormatInterfaceTable(ifaces), 3900)+"```")
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// truncate cuts s to at most n bytes, marking the cut with an ellipsis line
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// Back off to a rune boundary so the result stays valid UTF-8
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "\n…\n"
+}
+
+// requireAdmin answers non-admins with a refusal and reports whether the sender is
+// listed in TELEGRAM_ADMIN_IDS
+func requireAdmin(bot *tgbotapi.BotAPI, update tgbotapi.Update) bool {
+	if update.Message.From != nil && core.IsTelegramAdmin(update.Message.From.ID) {
+		return true
+	}
+	bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "⛔ Chỉ admin mới được dùng lệnh này"))
+	return false
+}
+
+// callbackAdmin is requireAdmin for inline buttons: non-admins get an alert instead
+func callbackAdmin(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery) bool {
+	if callback.From != nil && core.IsTelegramAdmin(callback.From.ID) {
+		return true
+	}
+	bot.Request(tgbotapi.NewCallbackWithAlert(callback.ID, "⛔ Chỉ admin mới được dùng nút này"))
+	return false
+}
+
+// HandleWANCommand handles the admin-only /wan <action> command
+func HandleWANCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+	if !requireAdmin(bot, update) {
+		return
+	}
+
+	if update.Message.CommandArguments() != "reconnect" {
+		bot.Send(tgbotapi.NewMessage(chatID, "Cách dùng: /wan reconnect"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), core.WANReconnectTimeout+30*time.Second)
+	defer cancel()
+
+	header := "🔌 WAN reconnect"
+	sentMsg, err := bot.Send(tgbotapi.NewMessage(chatID, header))
+	if err != nil {
+		log.Println("Error sending WAN message:", err)
+		return
+	}
+
+	progress := core.ThrottleProgress(2*time.Second, func(line string) {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n"+line))
+	})
+
+	result, err := core.ReconnectPPPoE(ctx, progress)
+	if err != nil {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n❌ "+err.Error()))
+		return
+	}
+
+	bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n"+core.FormatWANReconnect(result)))
+}
+
+// HandleClientsCommand handles /clients [search]
+func HandleClientsCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	leases, err := core.GetDHCPLeases(ctx)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Lỗi: "+err.Error()))
+		return
+	}
+
+	leases = core.SearchLeases(leases, update.Message.CommandArguments())
+	if len(leases) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "🔍 Không tìm thấy thiết bị nào"))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("💻 *Clients* (%d)\n```\n%s```", len(leases), truncate(core.FormatLeaseTable(leases), 3900)))
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
@@ -0,0 +1,23 @@
+package telegram
+
+import (
+	"log"
+	"strconv"
+	"super-bot/core"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// RegisterNotifier posts core notifications to the configured Telegram chat
+func RegisterNotifier(bot *tgbotapi.BotAPI) {
+	chatID, err := strconv.ParseInt(core.TelegramChatID, 10, 64)
+	if err != nil {
+		log.Println("⚠️  TELEGRAM_CHAT_ID not set or invalid, Telegram notifications disabled")
+		return
+	}
+	core.RegisterNotifier(func(text string) {
+		if _, err := bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf("❌ Telegram Error: Failed to send notification: %v", err)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"super-bot/core"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleMigrateCommand handles the admin-only /migrate <vm> <target-node>
+func HandleMigrateCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+	if !requireAdmin(bot, update) {
+		return
+	}
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) != 2 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Cách dùng: /migrate <vm> <node-đích>"))
+		return
+	}
+
+	// MigrateVM bounds the migration itself
+	ctx := context.Background()
+	vm, err := core.FindVM(ctx, args[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
+		return
+	}
+
+	header := fmt.Sprintf("🚚 Đang di chuyển %s (%d): %s → %s", vm.Name, vm.VMID, vm.Node, args[1])
+	sentMsg, err := bot.Send(tgbotapi.NewMessage(chatID, header))
+	if err != nil {
+		log.Println("Error sending migrate message:", err)
+		return
+	}
+
+	progress := core.ThrottleProgress(3*time.Second, func(line string) {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n"+line))
+	})
+
+	result := core.MigrateVM(ctx, vm, args[1], progress)
+	bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID,
+		core.FormatMigrationSummary([]core.MigrationResult{result})))
+}
+
+// HandleDrainCommand handles the admin-only /drain <node> [target-node]
+func HandleDrainCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+	if !requireAdmin(bot, update) {
+		return
+	}
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) < 1 || len(args) > 2 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Cách dùng: /drain <node> [node-đích]"))
+		return
+	}
+	target := ""
+	if len(args) == 2 {
+		target = args[1]
+	}
+
+	header := fmt.Sprintf("🧹 Đang dọn node %s", args[0])
+	sentMsg, err := bot.Send(tgbotapi.NewMessage(chatID, header))
+	if err != nil {
+		log.Println("Error sending drain message:", err)
+		return
+	}
+
+	progress := core.ThrottleProgress(3*time.Second, func(line string) {
+		bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n"+line))
+	})
+
+	// Each guest gets its own MigrationTimeout inside DrainNode
+	results, err := core.DrainNode(context.Background(), args[0], target, progress)
+	if err != nil {
+		if len(results) > 0 {
+			header += "\n" + core.FormatMigrationSummary(results)
+		}
+		bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n❌ "+err.Error()))
+		return
+	}
+
+	bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n"+core.FormatMigrationSummary(results)))
+}
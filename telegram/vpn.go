@@ -0,0 +1,262 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"super-bot/core"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// HandleFailoverCommand handles /failover [pin|unpin]; pin and unpin are admin only
+func HandleFailoverCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	action := update.Message.CommandArguments()
+	if (action == "pin" || action == "unpin") && !requireAdmin(bot, update) {
+		return
+	}
+
+	content := ""
+	switch action {
+	case "pin":
+		core.PinFailover(true)
+		content = "📌 Đã ghim node hiện tại, failover sẽ không tự chuyển\n"
+	case "unpin":
+		core.PinFailover(false)
+		content = "▶️ Đã bỏ ghim, failover tự chuyển trở lại\n"
+	}
+
+	bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, content+core.FormatFailoverStatus(core.GetFailoverStatus())))
+}
+
+// HandleConnectionsCommand handles /connections [search] (top active Sing-box connections)
+// and /connections kill <filter>
+func HandleConnectionsCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	args := update.Message.CommandArguments()
+	if filter, ok := strings.CutPrefix(args, "kill"); ok && (filter == "" || filter[0] == ' ') {
+		// Listing is open to everyone, closing live flows is admin-only
+		if !requireAdmin(bot, update) {
+			return
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, killConnections(ctx, strings.TrimSpace(filter))))
+		return
+	}
+
+	snap, err := core.GetConnections(ctx)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Lỗi: "+err.Error()))
+		return
+	}
+
+	conns := core.FilterConnections(snap.Connections, args)
+	if len(conns) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "🔍 Không có kết nối nào"))
+		return
+	}
+
+	header := fmt.Sprintf("🔗 *Connections* (%d) | Tổng ↑ %s ↓ %s",
+		len(conns), core.FormatBytes(snap.UploadTotal), core.FormatBytes(snap.DownloadTotal))
+	msg := tgbotapi.NewMessage(chatID, header+"\n```\n"+truncate(core.FormatConnectionsTable(conns, connectionsLimit), 3900)+"```")
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// connectionsLimit is how many connections /connections lists
+const connectionsLimit = 25
+
+// killConnections closes the connections matching filter and describes the result
+func killConnections(ctx context.Context, filter string) string {
+	if filter == "" {
+		return "Cách dùng: /connections kill <host|rule>"
+	}
+	closed, err := core.KillConnections(ctx, filter)
+	if err != nil {
+		return fmt.Sprintf("❌ Đã đóng %d kết nối rồi gặp lỗi: %s", closed, err.Error())
+	}
+	return fmt.Sprintf("✂️ Đã đóng %d kết nối khớp \"%s\"", closed, filter)
+}
+
+// HandleModeCommand handles /mode [rule|global|direct]: shows or changes the Clash routing mode
+func HandleModeCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Anyone may read the mode, only admins may change it
+	mode := strings.TrimSpace(update.Message.CommandArguments())
+	if mode != "" && !requireAdmin(bot, update) {
+		return
+	}
+	if mode != "" {
+		if err := core.SetClashMode(ctx, mode); err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Lỗi: "+err.Error()+"\nCách dùng: /mode [rule|global|direct]"))
+			return
+		}
+	}
+	current, err := core.GetClashMode(ctx)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Lỗi: "+err.Error()))
+		return
+	}
+
+	text := fmt.Sprintf("🧭 Mode hiện tại: `%s`", current)
+	if mode != "" {
+		text = fmt.Sprintf("🧭 Đã chuyển sang mode `%s`", current)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// HandleNodesCommand handles /nodes [group]: per-node delay history (last, median, p95, loss)
+func HandleNodesCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// History comes from the DELAY_HISTORY_INTERVAL tester only, so no test is run here
+	groups, err := core.ListProxyGroups(ctx)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Lỗi: "+err.Error()))
+		return
+	}
+
+	if name := strings.TrimSpace(update.Message.CommandArguments()); name != "" {
+		group, ok := core.FindProxyGroup(groups, name)
+		if !ok {
+			bot.Send(tgbotapi.NewMessage(chatID, "🔍 Không có nhóm "+name))
+			return
+		}
+		groups = []core.ProxyGroup{group}
+	}
+
+	header := fmt.Sprintf("📶 *Nodes* — timeout %s", core.SingboxDelayTimeout)
+	msg := tgbotapi.NewMessage(chatID, header+"\n```\n"+truncate(core.FormatNodesTable(groups), 3900)+"```")
+	msg.ParseMode = "Markdown"
+	bot.Send(msg)
+}
+
+// HandleSingboxCommand handles the admin-only /singbox outbounds | add <selector> <tag> [outbound-json]
+// | remove <selector> <tag> command that edits the sing-box config file and reloads it
+func HandleSingboxCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+	if !requireAdmin(bot, update) {
+		return
+	}
+
+	// The outbound JSON may contain spaces, so only the first three fields are split off
+	args := strings.SplitN(strings.TrimSpace(update.Message.CommandArguments()), " ", 4)
+	action := args[0]
+
+	if action == "outbounds" {
+		outbounds, err := core.ListOutbounds()
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Lỗi: "+err.Error()))
+			return
+		}
+		msg := tgbotapi.NewMessage(chatID, "📄 *Outbounds*\n```\n"+truncate(core.FormatOutboundsTable(outbounds), 3900)+"```")
+		msg.ParseMode = "Markdown"
+		bot.Send(msg)
+		return
+	}
+
+	if (action != "add" && action != "remove") || len(args) < 3 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Cách dùng:\n/singbox outbounds\n/singbox add <selector> <tag> [outbound-json]\n/singbox remove <selector> <tag>"))
+		return
+	}
+	selector, tag := args[1], args[2]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*core.SingboxReloadTimeout+time.Minute)
+	defer cancel()
+
+	lines := []string{fmt.Sprintf("🛠 sing-box %s %s ↔ %s", action, tag, selector)}
+	sentMsg, err := bot.Send(tgbotapi.NewMessage(chatID, lines[0]))
+	if err != nil {
+		log.Println("Error sending sing-box message:", err)
+		return
+	}
+	progress := func(line string) {
+		lines = append(lines, line)
+		bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, strings.Join(lines, "\n")))
+	}
+
+	if action == "remove" {
+		err = core.RemoveFromSelector(ctx, selector, tag, progress)
+	} else {
+		definition := ""
+		if len(args) == 4 {
+			definition = args[3]
+		}
+		err = core.AddToSelector(ctx, selector, tag, definition, progress)
+	}
+	if err != nil {
+		progress("❌ " + err.Error())
+	}
+}
+
+// HandleVPNLogsCommand handles the admin-only /vpnlogs [level] [minutes] | stop: keeps one message
+// updated with the latest sing-box log lines until the time runs out or /vpnlogs stop is sent in
+// the chat
+func HandleVPNLogsCommand(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+	if !requireAdmin(bot, update) {
+		return
+	}
+	key := fmt.Sprintf("tg:%d", chatID)
+	args := strings.Fields(update.Message.CommandArguments())
+
+	if len(args) > 0 && args[0] == "stop" {
+		text := "ℹ️ Không có stream log nào đang chạy"
+		if core.StopLogStream(key) {
+			text = "⏹ Đã dừng stream log"
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, text))
+		return
+	}
+	if core.LogStreamRunning(key) {
+		bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Đang có stream log, gửi /vpnlogs stop để dừng"))
+		return
+	}
+
+	level, minutes := "info", 0
+	if len(args) > 0 {
+		level = args[0]
+	}
+	if len(args) > 1 {
+		minutes, _ = strconv.Atoi(args[1])
+	}
+	duration := core.LogStreamLength(minutes)
+
+	header := fmt.Sprintf("📜 Sing-box logs (%s) trong %s", level, duration)
+	sentMsg, err := bot.Send(tgbotapi.NewMessage(chatID, header+"\n⏳ Đang chờ log..."))
+	if err != nil {
+		log.Println("Error sending vpnlogs message:", err)
+		return
+	}
+
+	// The message shows a rolling tail: older lines fall off once it is full
+	var tail []string
+	err = core.StartLogStream(key, level, duration, func(lines []string) {
+		tail = append(tail, lines...)
+		if len(tail) > 200 {
+			tail = tail[len(tail)-200:]
+		}
+		bot.Send(tgbotapi.NewEditMessageText(chatID, sentMsg.MessageID, header+"\n"+core.PackLogLines(tail, 3900)))
+	})
+
+	status := "⏹ Đã dừng stream log"
+	if err != nil {
+		status = "❌ Lỗi: " + err.Error() + "\nCách dùng: /vpnlogs [debug|info|warning|error] [phút] | stop"
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, status))
+}